@@ -0,0 +1,143 @@
+package main
+
+import (
+	"blockbook/internal/config"
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcparser"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
+	"context"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// addressesFlag collects the addresses given by repeating a flag or as a comma separated list. Addresses are
+// checksummed, So they match the addresses returned by the RPC client.
+type addressesFlag []string
+
+func (f *addressesFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *addressesFlag) Set(value string) error {
+	for _, address := range strings.Split(value, ",") {
+		address = strings.TrimSpace(address)
+		if !common.IsHexAddress(address) {
+			return errors.New(fmt.Sprintf("%q is not a valid address", address))
+		}
+
+		*f = append(*f, common.HexToAddress(address).Hex())
+	}
+
+	return nil
+}
+
+// rangeFlags are the flags of the commands which process the transactions of some addresses inside a block range.
+type rangeFlags struct {
+	addresses addressesFlag
+	from      uint64
+	to        uint64
+}
+
+func (f *rangeFlags) register(fs *flag.FlagSet, toUsage string) {
+	fs.Var(&f.addresses, "address", "The address to scan for, Can be repeated or given as a comma separated list")
+	fs.Uint64Var(&f.from, "from", 0, "The first block of the range")
+	fs.Uint64Var(&f.to, "to", 0, toUsage)
+}
+
+func (f *rangeFlags) validate(fs *flag.FlagSet) error {
+	if len(f.addresses) == 0 {
+		return errors.New("at least one --address is required")
+	}
+
+	fromSet := false
+	fs.Visit(func(fl *flag.Flag) {
+		if fl.Name == "from" {
+			fromSet = true
+		}
+	})
+	if !fromSet {
+		return errors.New("--from is required")
+	}
+
+	return nil
+}
+
+// backfillOptions contains the flags of the backfill command.
+type backfillOptions struct {
+	rangeFlags
+	tenant   string
+	snapshot string
+}
+
+// backfill subscribes the addresses for the tenant and indexes their transactions inside the block range into a
+// parser, On top of the state of the snapshot file if it exists. The parser state is written back into the snapshot
+// file afterward, So the server continues from it on the next start.
+func backfill(ctx context.Context, logger *zap.Logger, client bcclient.Client, parserOptions bccparser.Options, options backfillOptions) error {
+	parser, err := startFromSnapshot(ctx, logger, client, parserOptions, options.snapshot)
+	if err != nil {
+		return err
+	}
+	defer parser.Stop()
+
+	subscriptions := make([]bcparser.Subscription, 0, len(options.addresses))
+	for _, address := range options.addresses {
+		subscriptions = append(subscriptions, bcparser.Subscription{Address: address})
+	}
+	parser.SubscribeMany(options.tenant, subscriptions)
+
+	to := options.to
+	if to == 0 {
+		to = parser.CurrentBlockNumber()
+	}
+
+	logger.Sugar().Infof("indexing blocks %d to %d for %d addresses...", options.from, to, len(options.addresses))
+	if err := parser.Backfill(ctx, options.from, to); err != nil {
+		return errors.Wrap(err, "could not index blocks")
+	}
+	parser.Stop()
+
+	if err := saveSnapshot(parser, options.snapshot); err != nil {
+		return err
+	}
+
+	for _, address := range options.addresses {
+		logger.Sugar().Infof("indexed %d transactions of %s", len(parser.Transactions(options.tenant, address)), address)
+	}
+	logger.Sugar().Infof("snapshot written to %s", options.snapshot)
+
+	return nil
+}
+
+func runBackfill(ctx context.Context, cfg config.Config, logger *zap.Logger, args []string) error {
+	var options backfillOptions
+	fs := flag.NewFlagSet("backfill", flag.ExitOnError)
+	options.register(fs, "The last block of the range, Defaults to the last indexed block")
+	fs.StringVar(&options.tenant, "tenant", controller.AnonymousKeyID, "The tenant which the addresses are subscribed for, Defaults to the tenant of the requests when authentication is disabled")
+	fs.StringVar(&options.snapshot, "snapshot", cfg.Parser.Snapshot.Path, "The snapshot file path, Defaults to the snapshot path of the configuration")
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if err := options.validate(fs); err != nil {
+		return err
+	}
+	if options.snapshot == "" {
+		return errors.New("--snapshot is required if the snapshot path is not configured")
+	}
+
+	client, err := newBlockchainClient(cfg, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not create blockchain rpc client")
+	}
+
+	return backfill(ctx, logger, client, bccparser.Options{
+		IndexInterval:            cfg.Parser.IndexInterval,
+		BlockBatchSize:           cfg.Parser.Client.BlockBatchSize,
+		BalanceReconcileInterval: cfg.Parser.BalanceReconcileInterval,
+	}, options)
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"blockbook/internal/config"
+	"blockbook/pkg/bcclient"
+	cacheclient "blockbook/pkg/bcclient/cache"
+	ethclient "blockbook/pkg/bcclient/eth"
+	ratelimitclient "blockbook/pkg/bcclient/ratelimit"
+	"blockbook/pkg/errors"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// newBlockchainClient creates the rate limited and cached RPC client configured by cfg. Metrics are registered into
+// registerer unless it's nil.
+func newBlockchainClient(cfg config.Config, registerer prometheus.Registerer) (bcclient.Client, error) {
+	// the rate limits are applied to the http requests, So methods which send several requests are throttled by each
+	// of them.
+	transport := ratelimitclient.NewTransport(http.DefaultTransport, ratelimitclient.Options{
+		RequestsPerSecond: cfg.Parser.Client.RateLimit.RequestsPerSecond,
+		Burst:             cfg.Parser.Client.RateLimit.Burst,
+		MaxConcurrency:    cfg.Parser.Client.RateLimit.MaxConcurrency,
+		MaxRetries:        cfg.Parser.Client.RateLimit.MaxRetries,
+		Registerer:        registerer,
+	})
+	ethClient, err := ethclient.New(cfg.Parser.Client.RpcAddress, ethclient.Options{
+		Registerer:       registerer,
+		BlockBatchSize:   cfg.Parser.Client.BlockBatchSize,
+		ReceiptBatchSize: cfg.Parser.Client.ReceiptBatchSize,
+		Transport:        transport,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create eth client")
+	}
+
+	var client bcclient.Client = ethClient
+	if cfg.Parser.Client.Cache.Enabled {
+		client, err = cacheclient.New(client, cacheclient.Options{
+			Size:          cfg.Parser.Client.Cache.Size,
+			FinalityDepth: cfg.Parser.Client.Cache.FinalityDepth,
+			Dir:           cfg.Parser.Client.Cache.Dir,
+			Registerer:    registerer,
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not create cache client")
+		}
+	}
+
+	return client, nil
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"blockbook/internal/api/scopes"
+	"blockbook/internal/config"
+	"blockbook/internal/export"
+	fakeclient "blockbook/pkg/bcclient/fake"
+	"blockbook/pkg/bcparser"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	address1 = "0x627306090abaB3A6e1400e9345bC60c78a8BEf57"
+	address2 = "0xf17f52151EbEF6C7334FAD080c5704D77216b732"
+	address3 = "0xC5fdf4076b8F3A5357c5E395ab970B5B54098Fef"
+
+	testIndexInterval = 10 * time.Millisecond
+)
+
+func testParserOptions() bccparser.Options {
+	return bccparser.Options{
+		IndexInterval:            testIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+	}
+}
+
+func TestBackfill(t *testing.T) {
+	client := fakeclient.New()
+	client.AppendBlocks(2)
+	first := client.AppendBlock(fakeclient.NewTransaction(address1, address2, big.NewInt(1)))
+	client.AppendBlock(fakeclient.NewTransaction(address2, address3, big.NewInt(2)))
+	last := client.AppendBlock(fakeclient.NewTransaction(address3, address1, big.NewInt(3)))
+
+	path := filepath.Join(t.TempDir(), "snapshot.bin")
+	options := backfillOptions{
+		rangeFlags: rangeFlags{addresses: addressesFlag{address1}, from: first.Number},
+		tenant:     "tenant",
+		snapshot:   path,
+	}
+	require.NoError(t, backfill(context.Background(), zap.NewNop(), client, testParserOptions(), options))
+
+	// a second backfill adds to the same snapshot.
+	options.addresses = addressesFlag{address2}
+	options.to = last.Number
+	require.NoError(t, backfill(context.Background(), zap.NewNop(), client, testParserOptions(), options))
+
+	parser := bccparser.New(zap.NewNop(), client, testParserOptions())
+	t.Cleanup(parser.Stop)
+	require.NoError(t, restoreSnapshot(zap.NewNop(), parser, path))
+
+	txs := parser.Transactions("tenant", address1)
+	require.Len(t, txs, 2)
+	assert.Equal(t, []string{first.Transactions[0].Hash, last.Transactions[0].Hash}, []string{txs[0].Hash, txs[1].Hash})
+	assert.Len(t, parser.Transactions("tenant", address2), 2)
+	assert.Nil(t, parser.Transactions("another-tenant", address1))
+
+	options.from = last.Number + 1
+	assert.Error(t, backfill(context.Background(), zap.NewNop(), client, testParserOptions(), options))
+}
+
+func TestWriteScan(t *testing.T) {
+	client := fakeclient.New()
+	client.AppendBlocks(2)
+	first := client.AppendBlock(fakeclient.NewTransaction(address1, address2, big.NewInt(1)))
+	client.AppendBlock(fakeclient.NewTransaction(address2, address3, big.NewInt(2)))
+	last := client.AppendBlock(fakeclient.NewTransaction(address3, address1, big.NewInt(3)))
+
+	var output bytes.Buffer
+	flags := rangeFlags{addresses: addressesFlag{address1}, from: first.Number}
+	counts, err := writeScan(context.Background(), client, zap.NewNop(), flags, 2, export.FormatJSONL, &output)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{address1: 2}, counts)
+
+	hashes := make([]string, 0)
+	scanner := bufio.NewScanner(&output)
+	for scanner.Scan() {
+		var row export.Row
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+		assert.Equal(t, address1, row.Address)
+		hashes = append(hashes, row.Hash)
+	}
+	assert.Equal(t, []string{first.Transactions[0].Hash, last.Transactions[0].Hash}, hashes)
+}
+
+func TestVerifyAPIKeys(t *testing.T) {
+	var cfg config.Config
+	result, err := verifyAPIKeys(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "authentication is disabled", result)
+
+	cfg.Api.Auth.Enabled = true
+	_, err = verifyAPIKeys(cfg)
+	assert.Error(t, err)
+
+	hash := controller.HashAPIKey("key")
+	cfg.Api.Auth.Keys = []config.APIKey{
+		{ID: "reader", Hash: hash, Scopes: []string{scopes.ReadBlocks, scopes.ReadTransactions}},
+		{ID: "operator", Hash: hash, Scopes: []string{controller.WildcardScope}},
+	}
+	_, err = verifyAPIKeys(cfg)
+	require.NoError(t, err)
+
+	cfg.Api.Auth.Keys[0].Scopes = []string{"write:blocks"}
+	_, err = verifyAPIKeys(cfg)
+	assert.ErrorContains(t, err, "unknown scope")
+
+	cfg.Api.Auth.Keys[0].Hash = "key"
+	_, err = verifyAPIKeys(cfg)
+	assert.ErrorContains(t, err, "hash")
+}
+
+func TestRunChecks(t *testing.T) {
+	client := fakeclient.New()
+	client.AppendBlock(fakeclient.NewTransaction(address1, address2, big.NewInt(1)))
+
+	var output bytes.Buffer
+	require.NoError(t, runChecks(context.Background(), verifyChecks(config.Config{}, client), &output))
+	assert.Contains(t, output.String(), "ok      block receipts: block 1 has 1 receipts")
+
+	output.Reset()
+	client.InjectError(fakeclient.MethodBlockReceipts, errors.New("method not found"))
+	err := runChecks(context.Background(), verifyChecks(config.Config{}, client), &output)
+	assert.ErrorContains(t, err, "1 of 4 checks failed, 0 skipped")
+	assert.Contains(t, output.String(), "failed  block receipts: method not found")
+
+	output.Reset()
+	client.InjectError(fakeclient.MethodCurrentBlockNumber, errors.New("connection refused"))
+	err = runChecks(context.Background(), verifyChecks(config.Config{}, client), &output)
+	assert.ErrorContains(t, err, "1 of 4 checks failed, 2 skipped")
+	assert.Contains(t, output.String(), "failed  rpc: connection refused")
+	assert.Contains(t, output.String(), "skipped blocks: rpc check has not passed")
+	assert.Contains(t, output.String(), "skipped block receipts: rpc check has not passed")
+}
+
+func TestWriteStatus(t *testing.T) {
+	client := fakeclient.New()
+	head := client.AppendBlocks(3)
+
+	var output bytes.Buffer
+	require.NoError(t, writeStatus(context.Background(), zap.NewNop(), client, "http://node", "", &output))
+
+	var result status
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	assert.Equal(t, "http://node", result.RpcAddress)
+	assert.Equal(t, head, result.ChainHead)
+	assert.NotEmpty(t, result.HeadHash)
+	assert.Nil(t, result.Indexer)
+
+	// a missing snapshot only omits the indexer state.
+	path := filepath.Join(t.TempDir(), "snapshot.bin")
+	output.Reset()
+	require.NoError(t, writeStatus(context.Background(), zap.NewNop(), client, "http://node", path, &output))
+	assert.NotContains(t, output.String(), "indexer")
+
+	parser := bccparser.New(zap.NewNop(), client, testParserOptions())
+	t.Cleanup(parser.Stop)
+	<-parser.Ready()
+	parser.SubscribeMany("tenant1", []bcparser.Subscription{{Address: address1}, {Address: address2}})
+	parser.Subscribe("tenant2", bcparser.Subscription{Address: address1})
+	require.NoError(t, saveSnapshot(parser, path))
+
+	head = client.AppendBlocks(2)
+	output.Reset()
+	require.NoError(t, writeStatus(context.Background(), zap.NewNop(), client, "http://node", path, &output))
+
+	result = status{}
+	require.NoError(t, json.Unmarshal(output.Bytes(), &result))
+	require.NotNil(t, result.Indexer)
+	assert.Equal(t, path, result.Indexer.SnapshotPath)
+	assert.Equal(t, head-2, result.Indexer.LastIndexedBlock)
+	assert.Equal(t, uint64(2), result.Indexer.Lag)
+	assert.Equal(t, map[string]int{"tenant1": 2, "tenant2": 1}, result.Indexer.Subscriptions)
+	assert.NotEmpty(t, result.Indexer.SnapshotAge)
+
+	require.NoError(t, os.WriteFile(path, []byte("not a snapshot"), 0o600))
+	assert.Error(t, writeStatus(context.Background(), zap.NewNop(), client, "http://node", path, &output))
+}
+
+func TestReadSubscriptionsFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, lines ...string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(strings.Join(lines, "\n")), 0o600))
+
+		return path
+	}
+
+	lines, invalid, err := readSubscriptionsFile(zap.NewNop(), write("subscriptions.csv",
+		"address,label",
+		strings.ToLower(address1)+",hot wallet",
+		address1+",duplicate",
+		"0x1234",
+		address2,
+	))
+	require.NoError(t, err)
+	assert.Equal(t, 1, invalid)
+	assert.Equal(t, []subscriptionLine{{Address: address1, Label: "hot wallet"}, {Address: address2}}, lines)
+
+	lines, invalid, err = readSubscriptionsFile(zap.NewNop(), write("subscriptions.ndjson",
+		`{"address": "`+address1+`", "tags": ["exchange"], "ownerId": "team"}`,
+		"",
+		"not json",
+	))
+	require.NoError(t, err)
+	assert.Equal(t, 1, invalid)
+	assert.Equal(t, []subscriptionLine{{Address: address1, Tags: []string{"exchange"}, OwnerID: "team"}}, lines)
+
+	_, _, err = readSubscriptionsFile(zap.NewNop(), write("subscriptions.txt", address1))
+	assert.Error(t, err)
+}
+
+func TestImportSubscriptions(t *testing.T) {
+	client := fakeclient.New()
+	client.AppendBlocks(2)
+
+	dir := t.TempDir()
+	file := filepath.Join(dir, "subscriptions.csv")
+	require.NoError(t, os.WriteFile(file, []byte(strings.Join([]string{
+		"address,label",
+		strings.ToLower(address1) + ",hot wallet",
+		"0x1234",
+		address2,
+	}, "\n")), 0o600))
+
+	options := importOptions{path: file, tenant: "tenant", snapshot: filepath.Join(dir, "snapshot.bin")}
+	imported, invalid, err := importSubscriptions(context.Background(), zap.NewNop(), client, testParserOptions(), options)
+	require.NoError(t, err)
+	assert.Equal(t, 2, imported)
+	assert.Equal(t, 1, invalid)
+
+	// importing again keeps the existing subscriptions of the snapshot.
+	imported, _, err = importSubscriptions(context.Background(), zap.NewNop(), client, testParserOptions(), options)
+	require.NoError(t, err)
+	assert.Equal(t, 0, imported)
+
+	parser := bccparser.New(zap.NewNop(), client, testParserOptions())
+	t.Cleanup(parser.Stop)
+	require.NoError(t, restoreSnapshot(zap.NewNop(), parser, options.snapshot))
+
+	subscription, err := parser.Subscription("tenant", address1)
+	require.NoError(t, err)
+	assert.Equal(t, "hot wallet", subscription.Label)
+	_, err = parser.Subscription("tenant", address2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, parser.Subscriptions("tenant", bcparser.SubscriptionsQuery{}).Total)
+	assert.Equal(t, 0, parser.Subscriptions("another-tenant", bcparser.SubscriptionsQuery{}).Total)
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"blockbook/internal/config"
+	"blockbook/internal/export"
+	"blockbook/pkg/bcclient"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"blockbook/pkg/errors"
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"go.uber.org/zap"
+)
+
+// scanProgressBlocks is the number of blocks after which the progress of a scan is logged.
+const scanProgressBlocks = 1000
+
+// scan fetches the blocks of the range using client and calls fn for each matched transaction and each of the scanned
+// addresses involved in it, In chain order. batchSize is the number of blocks fetched at once.
+func scan(ctx context.Context, client bcclient.Client, logger *zap.Logger, flags rangeFlags, batchSize int, fn func(address string, tx *bcclient.Transaction) error) error {
+	to := flags.to
+	if to == 0 {
+		var err error
+		to, err = client.CurrentBlockNumber(ctx)
+		if err != nil {
+			return errors.Wrap(err, "could not get current block number")
+		}
+	}
+
+	logger.Sugar().Infof("scanning blocks %d to %d for %d addresses...", flags.from, to, len(flags.addresses))
+	scanned := 0
+	err := bccparser.ScanBlocks(ctx, client, flags.addresses, flags.from, to, batchSize, func(block bcclient.Block, txs []*bcclient.Transaction) error {
+		for _, tx := range txs {
+			for _, address := range flags.addresses {
+				if address != tx.FromAddress && address != tx.ToAddress {
+					continue
+				}
+
+				if err := fn(address, tx); err != nil {
+					return err
+				}
+			}
+		}
+
+		scanned++
+		if scanned%scanProgressBlocks == 0 {
+			logger.Sugar().Infof("scanned %d blocks, Reached block %d", scanned, block.Number)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not scan blocks")
+	}
+	logger.Sugar().Infof("scanned blocks %d to %d", flags.from, to)
+
+	return nil
+}
+
+// writeScan scans the block range of flags and writes the matched transactions in format to w. The number of
+// transactions written for each address is returned.
+func writeScan(ctx context.Context, client bcclient.Client, logger *zap.Logger, flags rangeFlags, batchSize int, format string, w io.Writer) (map[string]int, error) {
+	writer, err := export.NewWriter(format, w)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create export writer")
+	}
+
+	counts := make(map[string]int, len(flags.addresses))
+	err = scan(ctx, client, logger, flags, batchSize, func(address string, tx *bcclient.Transaction) error {
+		counts[address]++
+
+		return writer.Write(export.NewRow(address, tx))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, errors.Wrap(err, "could not write export")
+	}
+
+	return counts, nil
+}
+
+func runExport(ctx context.Context, cfg config.Config, logger *zap.Logger, args []string) error {
+	var flags rangeFlags
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	flags.register(fs, "The last block of the range, Defaults to the chain head")
+	format := fs.String("format", export.FormatCSV, "The export format, csv, jsonl or parquet")
+	outputPath := fs.String("output", "-", "The output file path, Defaults to stdout")
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if err := flags.validate(fs); err != nil {
+		return err
+	}
+	if _, ok := export.ContentTypes[*format]; !ok {
+		return export.ErrUnsupportedFormat
+	}
+
+	client, err := newBlockchainClient(cfg, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not create blockchain rpc client")
+	}
+
+	output, err := openOutput(*outputPath)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	counts, err := writeScan(ctx, client, logger, flags, cfg.Parser.Client.BlockBatchSize, *format, output)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range flags.addresses {
+		logger.Sugar().Infof("exported %d transactions of %s", counts[address], address)
+	}
+
+	return output.Close()
+}
+
+// openOutput opens the output file of a command, "-" stands for stdout. Closing the returned writer more than once is
+// safe.
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopCloser{os.Stdout}, nil
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not create output file")
+	}
+
+	return &onceCloser{file: file}, nil
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error {
+	return nil
+}
+
+type onceCloser struct {
+	file   *os.File
+	closed bool
+}
+
+func (c *onceCloser) Write(p []byte) (int, error) {
+	return c.file.Write(p) //nolint:wrapcheck
+}
+
+func (c *onceCloser) Close() error {
+	if c.closed {
+		return nil
+	}
+	c.closed = true
+
+	return c.file.Close() //nolint:wrapcheck
+}
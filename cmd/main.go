@@ -1,27 +1,105 @@
 package main
 
 import (
-	"blockbook/internal/api"
 	"blockbook/internal/config"
-	ethclient "blockbook/pkg/bcclient/eth"
-	bccparser "blockbook/pkg/bcparser/bcc"
-	"blockbook/pkg/controller"
-	"blockbook/pkg/errors"
 	"blockbook/pkg/logging"
 	"context"
 	"flag"
+	"fmt"
 	"log"
-	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 
 	"go.uber.org/zap"
 )
 
+// defaultCommand is run when no command is given, So the server can still be started without one.
+const defaultCommand = "serve"
+
+// command is a subcommand of the cli. Its flags are parsed by run itself.
+type command struct {
+	name        string
+	usage       string
+	description string
+	run         func(ctx context.Context, cfg config.Config, logger *zap.Logger, args []string) error
+}
+
+var commands = []command{
+	{
+		name:        "serve",
+		usage:       "serve",
+		description: "Starts the blockchain parser and its REST api server.",
+		run:         runServe,
+	},
+	{
+		name:        "backfill",
+		usage:       "backfill --address <address> --from <block> [--to <block>] [--tenant <tenant>] [--snapshot <path>]",
+		description: "Subscribes the given addresses, Indexes their transactions inside a block range and writes the parser state into a snapshot.",
+		run:         runBackfill,
+	},
+	{
+		name:        "export",
+		usage:       "export --address <address> --from <block> [--to <block>] [--format csv|jsonl|parquet] [--output <path>]",
+		description: "Exports the transactions of the given addresses inside a block range as csv, jsonl or parquet.",
+		run:         runExport,
+	},
+	{
+		name:        "import-subscriptions",
+		usage:       "import-subscriptions --file <path> [--tenant <tenant>] [--snapshot <path>]",
+		description: "Subscribes the addresses of a .csv, .ndjson or .jsonl subscriptions file and writes them into the parser snapshot.",
+		run:         runImportSubscriptions,
+	},
+	{
+		name:        "verify",
+		usage:       "verify",
+		description: "Verifies the configuration and the connectivity and capabilities of the RPC node.",
+		run:         runVerify,
+	},
+	{
+		name:        "status",
+		usage:       "status [--snapshot <path>]",
+		description: "Prints the chain head of the RPC node and the indexer state of the snapshot as json.",
+		run:         runStatus,
+	},
+}
+
+func usage() {
+	out := flag.CommandLine.Output()
+	_, _ = fmt.Fprintf(out, "Usage: %s [-configPath config.yml] <command> [flags]\n\nCommands:\n", os.Args[0])
+	for _, cmd := range commands {
+		_, _ = fmt.Fprintf(out, "  %s\n    \t%s\n", cmd.usage, cmd.description)
+	}
+	_, _ = fmt.Fprintf(out, "\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func findCommand(name string) (command, bool) {
+	for _, cmd := range commands {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
 func main() {
 	configPath := flag.String("configPath", "config.yml", "The config file path")
+	flag.Usage = usage
 	flag.Parse()
 
+	name, args := defaultCommand, flag.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+	cmd, ok := findCommand(name)
+	if !ok {
+		_, _ = fmt.Fprintf(flag.CommandLine.Output(), "unknown command %q\n\n", name)
+		flag.Usage()
+		os.Exit(2)
+	}
+
 	log.Println("loading config ...")
 	cfg, err := config.Load(*configPath)
 	if err != nil {
@@ -43,60 +121,7 @@ func main() {
 		}
 	}()
 
-	logger.Info("creating blockchain rpc client...")
-	bcClient, err := ethclient.New(cfg.Parser.Client.RpcAddress)
-	if err != nil {
-		logger.Fatal("could not create blockchain rpc client", zap.Error(err))
+	if err := cmd.run(ctx, cfg, logger, args); err != nil {
+		logger.Fatal("command failed", zap.String("command", name), zap.Error(err))
 	}
-	logger.Debug("blockchain rpc client created successfully")
-
-	logger.Info("creating blockchain parser...")
-	parser := bccparser.New(logger, bcClient, cfg.Parser.IndexInterval)
-	logger.Debug("blockchain parser created successfully")
-
-	logger.Info("creating webserver...")
-	server, err := api.NewServer(logger, api.Options{
-		Controller: controller.Options{
-			Addr:               cfg.Api.Server.Addr,
-			ReadTimeout:        cfg.Api.Server.ReadTimeout,
-			ReadHeaderTimeout:  cfg.Api.Server.ReadHeaderTimeout,
-			WriteTimeout:       cfg.Api.Server.WriteTimeout,
-			IdleTimeout:        cfg.Api.Server.IdleTimeout,
-			RequestTimeout:     cfg.Api.Server.RequestTimeout,
-			MaxHeaderBytes:     cfg.Api.Server.MaxHeaderBytes,
-			MetricsPath:        cfg.Api.Server.MetricsPath,
-			MetricsSubSystem:   cfg.Api.Server.MetricsSubSystem,
-			DefaultHandlerName: cfg.Api.Server.DefaultHandlerName,
-		},
-		BlockchainParser: parser,
-	})
-	if err != nil {
-		logger.Fatal("could not create webserver", zap.Error(err))
-	}
-	logger.Debug("webserver created successfully")
-
-	go func() {
-		logger.Sugar().Infof("starting webserver on address %s ...", cfg.Api.Server.Addr)
-		if err := server.ListenAndServe(); err != nil {
-			if !errors.Is(err, http.ErrServerClosed) {
-				logger.Error("webserver failed", zap.Error(err))
-			}
-		}
-	}()
-
-	<-ctx.Done() // Waiting for the interrupt
-
-	logger.Debug("shutting down...")
-	ctx, cancel := context.WithTimeout(context.Background(), cfg.GracefulShutdownTimeout)
-	defer cancel()
-
-	logger.Info("stopping webserver...")
-	if err := server.Shutdown(ctx); err != nil {
-		logger.Fatal("webserver failed to shut down in time", zap.Error(err))
-	}
-
-	logger.Info("stopping blockchain parser...")
-	parser.Stop()
-
-	logger.Debug("shut down successfully")
 }
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"blockbook/internal/api"
+	"blockbook/internal/config"
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcclient/cassette"
+	tracingclient "blockbook/pkg/bcclient/tracing"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
+	"blockbook/pkg/tracing"
+	"context"
+	"flag"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"go.uber.org/zap"
+)
+
+func runServe(ctx context.Context, cfg config.Config, logger *zap.Logger, args []string) error {
+	if err := flag.NewFlagSet("serve", flag.ExitOnError).Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+
+	logger.Info("setting up tracing...")
+	shutdownTracing, err := tracing.Setup(ctx, tracing.Options{
+		Enabled:     cfg.Tracing.Enabled,
+		Endpoint:    cfg.Tracing.Endpoint,
+		Insecure:    cfg.Tracing.Insecure,
+		ServiceName: cfg.Tracing.ServiceName,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not set up tracing")
+	}
+	logger.Debug("tracing set up successfully")
+
+	logger.Info("creating blockchain rpc client...")
+	rpcClient, err := newBlockchainClient(cfg, prometheus.DefaultRegisterer)
+	if err != nil {
+		return errors.Wrap(err, "could not create blockchain rpc client")
+	}
+	var recorder *cassette.Recorder
+	if cfg.Parser.Client.RecordCassette != "" {
+		recorder = cassette.NewRecorder(rpcClient)
+		rpcClient = recorder
+		logger.Sugar().Infof("recording rpc responses into %s", cfg.Parser.Client.RecordCassette)
+	}
+	bcClient := tracingclient.New(rpcClient)
+	logger.Debug("blockchain rpc client created successfully")
+
+	logger.Info("creating blockchain parser...")
+	parser := bccparser.New(logger, bcClient, bccparser.Options{
+		IndexInterval:            cfg.Parser.IndexInterval,
+		BlockBatchSize:           cfg.Parser.Client.BlockBatchSize,
+		BalanceReconcileInterval: cfg.Parser.BalanceReconcileInterval,
+		MaxLag:                   cfg.Parser.Liveness.MaxLag,
+		MaxStaleness:             cfg.Parser.Liveness.MaxStaleness,
+		Registerer:               prometheus.DefaultRegisterer,
+	})
+	logger.Debug("blockchain parser created successfully")
+
+	var snapshotsDone <-chan struct{}
+	if cfg.Parser.Snapshot.Path != "" {
+		logger.Info("restoring blockchain parser snapshot...")
+		if err := restoreSnapshot(logger, parser, cfg.Parser.Snapshot.Path); err != nil {
+			parser.Stop()
+
+			return err
+		}
+		snapshotsDone = startSnapshots(ctx, logger, parser, cfg.Parser.Snapshot.Path, cfg.Parser.Snapshot.Interval)
+	}
+
+	healthRegistry := controller.NewHealthRegistry()
+	healthRegistry.AddReadinessCheck("parser", parser.CheckReady)
+	healthRegistry.AddReadinessCheck("rpc", func(ctx context.Context) error {
+		return bcclient.CheckConnectivity(ctx, bcClient)
+	})
+	healthRegistry.AddLivenessCheck("indexer", parser.CheckLive)
+
+	var apiKeyStore controller.APIKeyStore
+	if cfg.Api.Auth.Enabled {
+		keys := make([]controller.APIKey, 0, len(cfg.Api.Auth.Keys))
+		for _, key := range cfg.Api.Auth.Keys {
+			keys = append(keys, controller.APIKey{
+				ID:       key.ID,
+				Hash:     key.Hash,
+				Scopes:   key.Scopes,
+				TenantID: key.TenantID,
+			})
+		}
+		apiKeyStore = controller.NewMemoryAPIKeyStore(keys...)
+		logger.Sugar().Infof("api authentication enabled with %d keys", len(keys))
+	}
+
+	logger.Info("creating webserver...")
+	server, err := api.NewServer(logger, api.Options{
+		Controller: controller.Options{
+			Addr:               cfg.Api.Server.Addr,
+			ReadTimeout:        cfg.Api.Server.ReadTimeout,
+			ReadHeaderTimeout:  cfg.Api.Server.ReadHeaderTimeout,
+			WriteTimeout:       cfg.Api.Server.WriteTimeout,
+			IdleTimeout:        cfg.Api.Server.IdleTimeout,
+			RequestTimeout:     cfg.Api.Server.RequestTimeout,
+			MaxHeaderBytes:     cfg.Api.Server.MaxHeaderBytes,
+			MetricsPath:        cfg.Api.Server.MetricsPath,
+			MetricsSubSystem:   cfg.Api.Server.MetricsSubSystem,
+			DefaultHandlerName: cfg.Api.Server.DefaultHandlerName,
+			ServiceName:        cfg.Tracing.ServiceName,
+			TrustedProxies:     cfg.Api.Server.TrustedProxies,
+		},
+		BlockchainParser: parser,
+		Indexer:          parser,
+		APIKeyStore:      apiKeyStore,
+		HealthRegistry:   healthRegistry,
+		PublicRateLimits: groupRateLimits(cfg.Api.RateLimit.Public),
+		AdminRateLimits:  groupRateLimits(cfg.Api.RateLimit.Admin),
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not create webserver")
+	}
+	logger.Debug("webserver created successfully")
+
+	go func() {
+		logger.Sugar().Infof("starting webserver on address %s ...", cfg.Api.Server.Addr)
+		if err := server.ListenAndServe(); err != nil {
+			if !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("webserver failed", zap.Error(err))
+			}
+		}
+	}()
+
+	<-ctx.Done() // Waiting for the interrupt
+
+	logger.Debug("shutting down...")
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.GracefulShutdownTimeout)
+	defer cancel()
+
+	logger.Info("stopping webserver...")
+	if err := server.Shutdown(ctx); err != nil {
+		return errors.Wrap(err, "webserver failed to shut down in time")
+	}
+
+	logger.Info("stopping blockchain parser...")
+	parser.Stop()
+
+	if cfg.Parser.Snapshot.Path != "" {
+		<-snapshotsDone
+		logger.Info("taking blockchain parser snapshot...")
+		if err := saveSnapshot(parser, cfg.Parser.Snapshot.Path); err != nil {
+			logger.Error("could not take snapshot", zap.Error(err))
+		}
+	}
+
+	if recorder != nil {
+		logger.Info("saving recorded rpc responses...")
+		if err := recorder.SaveFile(cfg.Parser.Client.RecordCassette); err != nil {
+			logger.Error("could not save recorded rpc responses", zap.Error(err))
+		}
+	}
+
+	logger.Info("flushing traces...")
+	if err := shutdownTracing(ctx); err != nil {
+		logger.Error("could not flush traces", zap.Error(err))
+	}
+
+	logger.Debug("shut down successfully")
+
+	return nil
+}
+
+func groupRateLimits(limits config.GroupRateLimits) controller.GroupRateLimits {
+	return controller.GroupRateLimits{
+		PerKey: controller.RateLimit{
+			RequestsPerSecond: limits.PerKey.RequestsPerSecond,
+			Burst:             limits.PerKey.Burst,
+		},
+		PerIP: controller.RateLimit{
+			RequestsPerSecond: limits.PerIP.RequestsPerSecond,
+			Burst:             limits.PerIP.Burst,
+		},
+	}
+}
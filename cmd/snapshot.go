@@ -0,0 +1,120 @@
+package main
+
+import (
+	"blockbook/pkg/bcclient"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"blockbook/pkg/errors"
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// restoreSnapshot restores the parser state from the snapshot file at path. A missing file is not an error, So the
+// first start with snapshots enabled begins from the chain head.
+func restoreSnapshot(logger *zap.Logger, parser *bccparser.Parser, path string) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		logger.Sugar().Infof("no snapshot found at %s, Starting from the chain head", path)
+
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "could not open snapshot file")
+	}
+	defer file.Close()
+
+	if err := parser.Restore(file); err != nil {
+		return errors.Wrap(err, "could not restore snapshot")
+	}
+
+	return nil
+}
+
+// startFromSnapshot creates a parser on top of the state of the snapshot file at path and waits until it is ready, So
+// offline commands can change the state which the server continues from. The caller should stop the parser.
+func startFromSnapshot(ctx context.Context, logger *zap.Logger, client bcclient.Client, options bccparser.Options, path string) (*bccparser.Parser, error) {
+	parser := bccparser.New(logger, client, options)
+	if err := restoreSnapshot(logger, parser, path); err != nil {
+		parser.Stop()
+
+		return nil, err
+	}
+
+	select {
+	case <-ctx.Done():
+		parser.Stop()
+
+		return nil, ctx.Err() //nolint:wrapcheck
+	case <-parser.Ready():
+	}
+
+	return parser, nil
+}
+
+// saveSnapshot writes a snapshot of the parser state into a temporary file next to path and renames it afterward, So
+// a crash while writing never leaves a partial snapshot behind.
+func saveSnapshot(parser *bccparser.Parser, path string) error {
+	file, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "could not create snapshot file")
+	}
+	defer os.Remove(file.Name())
+
+	if err := parser.Snapshot(file); err != nil {
+		_ = file.Close()
+
+		return errors.Wrap(err, "could not write snapshot")
+	}
+	if err := file.Sync(); err != nil {
+		_ = file.Close()
+
+		return errors.Wrap(err, "could not sync snapshot file")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "could not close snapshot file")
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		return errors.Wrap(err, "could not replace snapshot file")
+	}
+
+	return nil
+}
+
+// startSnapshots takes a snapshot of the parser every interval until ctx is done. The returned channel is closed once
+// it has stopped, So the final snapshot is not overwritten by a periodic one.
+func startSnapshots(ctx context.Context, logger *zap.Logger, parser *bccparser.Parser, path string, interval time.Duration) <-chan struct{} {
+	done := make(chan struct{})
+	if interval <= 0 {
+		close(done)
+
+		return done
+	}
+
+	go func() {
+		defer close(done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+
+			case <-ticker.C:
+				if err := saveSnapshot(parser, path); err != nil {
+					logger.Error("could not take snapshot", zap.Error(err))
+
+					continue
+				}
+				logger.Debug("snapshot taken")
+			}
+		}
+	}()
+
+	return done
+}
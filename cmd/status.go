@@ -0,0 +1,127 @@
+package main
+
+import (
+	"blockbook/internal/config"
+	"blockbook/pkg/bcclient"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"blockbook/pkg/errors"
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// status is the output of the status command.
+type status struct {
+	RpcAddress    string    `json:"rpcAddress"`
+	ChainHead     uint64    `json:"chainHead"`
+	HeadHash      string    `json:"headHash"`
+	HeadTimestamp time.Time `json:"headTimestamp"`
+	// HeadAge is the time passed since the head block has been mined, It grows when the node is out of sync.
+	HeadAge string `json:"headAge"`
+	// Indexer is the state of the indexer according to its snapshot, It's omitted if there is no snapshot.
+	Indexer *indexerStatus `json:"indexer,omitempty"`
+}
+
+// indexerStatus is the state of the indexer stored in a snapshot.
+type indexerStatus struct {
+	SnapshotPath string `json:"snapshotPath"`
+	// SnapshotAge is the time passed since the snapshot has been written. The indexer may have progressed since then.
+	SnapshotAge      string `json:"snapshotAge"`
+	LastIndexedBlock uint64 `json:"lastIndexedBlock"`
+	// Lag is the number of blocks which the snapshot is behind the current chain head.
+	Lag uint64 `json:"lag"`
+	// Subscriptions is the number of subscriptions of each tenant.
+	Subscriptions map[string]int `json:"subscriptions"`
+}
+
+func runStatus(ctx context.Context, cfg config.Config, logger *zap.Logger, args []string) error {
+	fs := flag.NewFlagSet("status", flag.ExitOnError)
+	snapshot := fs.String("snapshot", cfg.Parser.Snapshot.Path, "The snapshot file path, Defaults to the snapshot path of the configuration")
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+
+	client, err := newBlockchainClient(cfg, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not create blockchain rpc client")
+	}
+
+	return writeStatus(ctx, logger, client, cfg.Parser.Client.RpcAddress, *snapshot, os.Stdout)
+}
+
+// writeStatus writes the status of the RPC node which client is connected to, And the state of the indexer stored in
+// the snapshot file at snapshotPath, as json to w. The indexer state is omitted if snapshotPath is empty or the file
+// does not exist yet.
+func writeStatus(ctx context.Context, logger *zap.Logger, client bcclient.Client, rpcAddress string, snapshotPath string, w io.Writer) error {
+	head, err := client.CurrentBlockNumber(ctx)
+	if err != nil {
+		return errors.Wrap(err, "could not get current block number")
+	}
+
+	block, err := client.Block(ctx, head)
+	if err != nil {
+		return errors.Wrap(err, "could not get head block")
+	}
+
+	result := status{
+		RpcAddress:    rpcAddress,
+		ChainHead:     head,
+		HeadHash:      block.Hash,
+		HeadTimestamp: block.Timestamp,
+		HeadAge:       time.Since(block.Timestamp).Round(time.Second).String(),
+	}
+
+	if snapshotPath != "" {
+		result.Indexer, err = readIndexerStatus(snapshotPath, head)
+		if errors.Is(err, os.ErrNotExist) {
+			logger.Sugar().Warnf("no snapshot found at %s, The indexer state is not available", snapshotPath)
+		} else if err != nil {
+			return err
+		}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(result); err != nil {
+		return errors.Wrap(err, "could not write status")
+	}
+
+	return nil
+}
+
+// readIndexerStatus reads the indexer state of the snapshot file at path and compares it with the chain head.
+func readIndexerStatus(path string, head uint64) (*indexerStatus, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+	defer file.Close()
+
+	stat, err := file.Stat()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not stat snapshot file")
+	}
+
+	info, err := bccparser.ReadSnapshotInfo(file)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read snapshot")
+	}
+
+	var lag uint64
+	if head > info.LastIndexedBlock {
+		lag = head - info.LastIndexedBlock
+	}
+
+	return &indexerStatus{
+		SnapshotPath:     path,
+		SnapshotAge:      time.Since(stat.ModTime()).Round(time.Second).String(),
+		LastIndexedBlock: info.LastIndexedBlock,
+		Lag:              lag,
+		Subscriptions:    info.Subscriptions,
+	}, nil
+}
@@ -0,0 +1,217 @@
+package main
+
+import (
+	"blockbook/internal/config"
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcparser"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.uber.org/zap"
+)
+
+// subscriptionLine is a subscription of a subscriptions file. It has the same format as the lines of the bulk
+// subscribe endpoint.
+type subscriptionLine struct {
+	Address string   `json:"address"`
+	Label   string   `json:"label,omitempty"`
+	Tags    []string `json:"tags,omitempty"`
+	OwnerID string   `json:"ownerId,omitempty"`
+}
+
+// readSubscriptionsFile reads the subscriptions of a .csv, .ndjson or .jsonl file. Addresses are checksummed and
+// duplicate addresses are skipped, Lines which could not be parsed or contain an invalid address are logged and
+// counted as invalid.
+func readSubscriptionsFile(logger *zap.Logger, path string) ([]subscriptionLine, int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, errors.Wrap(err, "could not open subscriptions file")
+	}
+	defer file.Close()
+
+	var lines []subscriptionLine
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		lines, err = readCSVSubscriptions(file)
+	case ".ndjson", ".jsonl":
+		lines, err = readNDJSONSubscriptions(file)
+	default:
+		return nil, 0, errors.New("subscriptions file should be a .csv, .ndjson or .jsonl file")
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+
+	result := make([]subscriptionLine, 0, len(lines))
+	seen := make(map[string]struct{}, len(lines))
+	invalid := 0
+	for i, line := range lines {
+		if !common.IsHexAddress(line.Address) {
+			logger.Warn("skipping invalid address", zap.Int("line", i+1), zap.String("address", line.Address))
+			invalid++
+
+			continue
+		}
+
+		line.Address = common.HexToAddress(line.Address).Hex()
+		if _, ok := seen[line.Address]; ok {
+			continue
+		}
+		seen[line.Address] = struct{}{}
+
+		result = append(result, line)
+	}
+
+	return result, invalid, nil
+}
+
+// readNDJSONSubscriptions parses a file which each line of it is a subscriptionLine JSON object. Lines which are not
+// valid JSON are returned as their raw text, So they are reported as invalid addresses.
+func readNDJSONSubscriptions(r io.Reader) ([]subscriptionLine, error) {
+	lines := make([]subscriptionLine, 0)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+
+		var line subscriptionLine
+		if err := json.Unmarshal([]byte(text), &line); err != nil {
+			line = subscriptionLine{Address: text}
+		}
+
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "could not read subscriptions file")
+	}
+
+	return lines, nil
+}
+
+// readCSVSubscriptions parses a file which the first column of each row is an address and the optional second column
+// is its label. A header row is skipped if present.
+func readCSVSubscriptions(r io.Reader) ([]subscriptionLine, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	lines := make([]subscriptionLine, 0)
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read subscriptions file")
+		}
+
+		address := strings.TrimSpace(record[0])
+		if address == "" || (i == 0 && strings.EqualFold(address, "address")) {
+			continue
+		}
+
+		line := subscriptionLine{Address: address}
+		if len(record) > 1 {
+			line.Label = strings.TrimSpace(record[1])
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, nil
+}
+
+// importOptions contains the flags of the import-subscriptions command.
+type importOptions struct {
+	path     string
+	tenant   string
+	snapshot string
+}
+
+// importSubscriptions subscribes the addresses of the subscriptions file for the tenant on top of the state of the
+// snapshot file, And writes the parser state back into it, So the server watches them from its next start. It returns
+// the number of new subscriptions and the number of invalid lines.
+func importSubscriptions(ctx context.Context, logger *zap.Logger, client bcclient.Client, parserOptions bccparser.Options, options importOptions) (int, int, error) {
+	lines, invalid, err := readSubscriptionsFile(logger, options.path)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	parser, err := startFromSnapshot(ctx, logger, client, parserOptions, options.snapshot)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer parser.Stop()
+
+	subscriptions := make([]bcparser.Subscription, 0, len(lines))
+	for _, line := range lines {
+		subscriptions = append(subscriptions, bcparser.Subscription{
+			Address: line.Address,
+			Label:   line.Label,
+			Tags:    line.Tags,
+			OwnerID: line.OwnerID,
+		})
+	}
+
+	imported := 0
+	for _, subscribed := range parser.SubscribeMany(options.tenant, subscriptions) {
+		if subscribed {
+			imported++
+		}
+	}
+	parser.Stop()
+
+	if err := saveSnapshot(parser, options.snapshot); err != nil {
+		return 0, 0, err
+	}
+
+	return imported, invalid, nil
+}
+
+func runImportSubscriptions(ctx context.Context, cfg config.Config, logger *zap.Logger, args []string) error {
+	var options importOptions
+	fs := flag.NewFlagSet("import-subscriptions", flag.ExitOnError)
+	fs.StringVar(&options.path, "file", "", "The .csv, .ndjson or .jsonl subscriptions file")
+	fs.StringVar(&options.tenant, "tenant", controller.AnonymousKeyID, "The tenant which the addresses are subscribed for, Defaults to the tenant of the requests when authentication is disabled")
+	fs.StringVar(&options.snapshot, "snapshot", cfg.Parser.Snapshot.Path, "The snapshot file path, Defaults to the snapshot path of the configuration")
+	if err := fs.Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+	if options.path == "" {
+		return errors.New("--file is required")
+	}
+	if options.snapshot == "" {
+		return errors.New("--snapshot is required if the snapshot path is not configured")
+	}
+
+	client, err := newBlockchainClient(cfg, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not create blockchain rpc client")
+	}
+
+	imported, invalid, err := importSubscriptions(ctx, logger, client, bccparser.Options{
+		IndexInterval:            cfg.Parser.IndexInterval,
+		BlockBatchSize:           cfg.Parser.Client.BlockBatchSize,
+		BalanceReconcileInterval: cfg.Parser.BalanceReconcileInterval,
+	}, options)
+	if err != nil {
+		return err
+	}
+	logger.Sugar().Infof("imported %d subscriptions into %s, Skipped %d invalid lines", imported, options.snapshot, invalid)
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"blockbook/internal/api/scopes"
+	"blockbook/internal/config"
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/errors"
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
+	"go.uber.org/zap"
+)
+
+// verifyCheck is a single check of the verify command. It returns a short description of what it has verified. The
+// check is skipped if the check named dependsOn has not passed, Since its result would be meaningless.
+type verifyCheck struct {
+	name      string
+	dependsOn string
+	check     func(ctx context.Context) (string, error)
+}
+
+func runVerify(ctx context.Context, cfg config.Config, _ *zap.Logger, args []string) error {
+	if err := flag.NewFlagSet("verify", flag.ExitOnError).Parse(args); err != nil {
+		return errors.Wrap(err, "could not parse flags")
+	}
+
+	client, err := newBlockchainClient(cfg, nil)
+	if err != nil {
+		return errors.Wrap(err, "could not create blockchain rpc client")
+	}
+
+	return runChecks(ctx, verifyChecks(cfg, client), os.Stdout)
+}
+
+// verifyChecks returns the checks of the configuration and the RPC node which client is connected to.
+func verifyChecks(cfg config.Config, client bcclient.Client) []verifyCheck {
+	var head uint64
+
+	return []verifyCheck{
+		{name: "api keys", check: func(context.Context) (string, error) {
+			return verifyAPIKeys(cfg)
+		}},
+		{name: "rpc", check: func(ctx context.Context) (string, error) {
+			var err error
+			head, err = client.CurrentBlockNumber(ctx)
+
+			return fmt.Sprintf("chain head is %d", head), err
+		}},
+		{name: "blocks", dependsOn: "rpc", check: func(ctx context.Context) (string, error) {
+			blocks, err := client.Blocks(ctx, head, head)
+			if err != nil {
+				return "", err //nolint:wrapcheck
+			}
+
+			return fmt.Sprintf("block %d has %d transactions", head, blocks[0].TransactionCount), nil
+		}},
+		{name: "block receipts", dependsOn: "rpc", check: func(ctx context.Context) (string, error) {
+			receipts, err := client.BlockReceipts(ctx, head)
+
+			return fmt.Sprintf("block %d has %d receipts", head, len(receipts)), err
+		}},
+	}
+}
+
+// runChecks runs all checks and writes their result to w. An error is returned if any of them fails or is skipped.
+func runChecks(ctx context.Context, checks []verifyCheck, w io.Writer) error {
+	passed := make(map[string]bool, len(checks))
+	failed, skipped := 0, 0
+	for _, c := range checks {
+		if c.dependsOn != "" && !passed[c.dependsOn] {
+			skipped++
+			_, _ = fmt.Fprintf(w, "skipped %s: %s check has not passed\n", c.name, c.dependsOn)
+
+			continue
+		}
+
+		result, err := c.check(ctx)
+		if err != nil {
+			failed++
+			_, _ = fmt.Fprintf(w, "failed  %s: %s\n", c.name, err)
+
+			continue
+		}
+
+		passed[c.name] = true
+		_, _ = fmt.Fprintf(w, "ok      %s: %s\n", c.name, result)
+	}
+
+	if failed > 0 || skipped > 0 {
+		return errors.New(fmt.Sprintf("%d of %d checks failed, %d skipped", failed, len(checks), skipped))
+	}
+
+	return nil
+}
+
+// verifyAPIKeys checks that the configured API keys have an ID, a valid hash and known scopes.
+func verifyAPIKeys(cfg config.Config) (string, error) {
+	if !cfg.Api.Auth.Enabled {
+		return "authentication is disabled", nil
+	}
+	if len(cfg.Api.Auth.Keys) == 0 {
+		return "", errors.New("authentication is enabled without any keys")
+	}
+
+	for i, key := range cfg.Api.Auth.Keys {
+		if key.ID == "" {
+			return "", errors.New(fmt.Sprintf("key %d does not have an id", i))
+		}
+		if hash, err := hex.DecodeString(key.Hash); err != nil || len(hash) != 32 {
+			return "", errors.New(fmt.Sprintf("hash of key %s is not a hex encoded SHA-256 hash", key.ID))
+		}
+		for _, scope := range key.Scopes {
+			if !slices.Contains(scopes.All, scope) {
+				return "", errors.New(fmt.Sprintf("key %s has unknown scope %q", key.ID, scope))
+			}
+		}
+	}
+
+	return fmt.Sprintf("%d keys are valid", len(cfg.Api.Auth.Keys)), nil
+}
@@ -0,0 +1,1016 @@
+package api
+
+import (
+	"blockbook/internal/api/controllers/address"
+	"blockbook/internal/api/controllers/admin"
+	"blockbook/internal/api/scopes"
+	"blockbook/internal/api/views"
+	"blockbook/pkg/bcclient"
+	fakeclient "blockbook/pkg/bcclient/fake"
+	"blockbook/pkg/bcparser"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
+	"blockbook/pkg/tracing"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/parquet-go/parquet-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+const (
+	fakeIndexInterval = 10 * time.Millisecond
+	fakeWaitTimeout   = 5 * time.Second
+
+	wallet1PublicAddress = "0x627306090abaB3A6e1400e9345bC60c78a8BEf57"
+	wallet2PublicAddress = "0xf17f52151EbEF6C7334FAD080c5704D77216b732"
+	wallet3PublicAddress = "0xC5fdf4076b8F3A5357c5E395ab970B5B54098Fef"
+)
+
+type apiResponse[T any] struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Result  T      `json:"result"`
+}
+
+type currentBlockResponse struct {
+	LastIndexedBlock uint64 `json:"lastIndexedBlock"`
+}
+
+type subscribeRequestBody struct {
+	Address string `json:"address"`
+}
+
+type transactionsResponse struct {
+	Transactions []views.Transaction `json:"transactions"`
+}
+
+// exportedTransaction is a row of the exported histories.
+type exportedTransaction struct {
+	Address     string    `json:"address" parquet:"address"`
+	Hash        string    `json:"hash" parquet:"hash"`
+	BlockNumber uint64    `json:"blockNumber" parquet:"blockNumber"`
+	AmountWei   string    `json:"amountWei" parquet:"amountWei"`
+	AmountEther string    `json:"amountEther" parquet:"amountEther"`
+	CreatedAt   time.Time `json:"createdAt" parquet:"createdAt,timestamp(millisecond)"`
+}
+
+type transactionResponse struct {
+	Transaction views.IndexedTransaction `json:"transaction"`
+}
+
+type statsResponse struct {
+	Stats views.Stats `json:"stats"`
+}
+
+// useFreshMetricsRegistry makes the servers created by the test register their metrics into a new registry, Since
+// registering the http metrics twice into the default registry panics.
+func useFreshMetricsRegistry(t *testing.T) {
+	t.Helper()
+
+	registerer, gatherer := prometheus.DefaultRegisterer, prometheus.DefaultGatherer
+	registry := prometheus.NewRegistry()
+	prometheus.DefaultRegisterer, prometheus.DefaultGatherer = registry, registry
+	t.Cleanup(func() {
+		prometheus.DefaultRegisterer, prometheus.DefaultGatherer = registerer, gatherer
+	})
+}
+
+func setupFakeServer(t *testing.T) (http.Handler, *fakeclient.Client) {
+	t.Helper()
+	useFreshMetricsRegistry(t)
+
+	client := fakeclient.New()
+	client.AppendBlocks(3)
+
+	logger := zap.NewNop()
+	parser := bccparser.New(logger, client, bccparser.Options{
+		IndexInterval:            fakeIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+	})
+	t.Cleanup(parser.Stop)
+
+	server, err := NewServer(logger, Options{
+		BlockchainParser: parser,
+	})
+	require.NoError(t, err)
+
+	select {
+	case <-parser.Ready():
+	case <-time.After(fakeWaitTimeout):
+		t.Fatal("parser did not become ready")
+	}
+
+	return server.Handler, client
+}
+
+func parseApiResponse[T any](t *testing.T, rec *httptest.ResponseRecorder, res *apiResponse[T]) {
+	assert.Equal(t, 200, rec.Code)
+
+	err := json.Unmarshal(rec.Body.Bytes(), res)
+	assert.NoError(t, err)
+	assert.True(t, res.Success)
+}
+
+func getCurrentBlock(t *testing.T, handler http.Handler) uint64 {
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/public/api/v1/block/current", nil)
+	if err != nil {
+		panic(err)
+	}
+	handler.ServeHTTP(rec, req)
+
+	var res apiResponse[currentBlockResponse]
+	parseApiResponse(t, rec, &res)
+
+	return res.Result.LastIndexedBlock
+}
+
+func subscribeAddress(t *testing.T, handler http.Handler, address string) {
+	body, err := json.Marshal(subscribeRequestBody{
+		Address: address,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("POST", "/public/api/v1/address/subscribe", bytes.NewReader(body))
+	if err != nil {
+		panic(err)
+	}
+	handler.ServeHTTP(rec, req)
+
+	var res apiResponse[struct{}]
+	parseApiResponse(t, rec, &res)
+}
+
+func getTransactions(t *testing.T, handler http.Handler, address string, query string) []views.Transaction {
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/public/api/v1/address/"+address+"/transactions?"+query, nil)
+	if err != nil {
+		panic(err)
+	}
+	handler.ServeHTTP(rec, req)
+
+	var res apiResponse[transactionsResponse]
+	parseApiResponse(t, rec, &res)
+
+	return res.Result.Transactions
+}
+
+func getTransaction(t *testing.T, handler http.Handler, hash string) views.IndexedTransaction {
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/public/api/v1/tx/"+hash, nil)
+	if err != nil {
+		panic(err)
+	}
+	handler.ServeHTTP(rec, req)
+
+	var res apiResponse[transactionResponse]
+	parseApiResponse(t, rec, &res)
+
+	return res.Result.Transaction
+}
+
+func TestTransactionsWithFakeClient(t *testing.T) {
+	handler, client := setupFakeServer(t)
+
+	subscribeAddress(t, handler, wallet1PublicAddress)
+
+	amount := big.NewInt(1000)
+	block := client.AppendBlock(
+		fakeclient.NewTransaction(wallet1PublicAddress, wallet2PublicAddress, amount),
+		fakeclient.NewTransaction(wallet2PublicAddress, wallet3PublicAddress, amount),
+	)
+	require.Eventually(t, func() bool {
+		return getCurrentBlock(t, handler) >= block.Number
+	}, fakeWaitTimeout, fakeIndexInterval)
+
+	txs := getTransactions(t, handler, wallet1PublicAddress, "")
+	require.Len(t, txs, 1)
+	assert.Equal(t, block.Transactions[0].Hash, txs[0].Hash)
+	assert.Equal(t, wallet2PublicAddress, txs[0].ToAddress)
+	assert.Equal(t, amount.String(), txs[0].Amount)
+
+	tx := getTransaction(t, handler, block.Transactions[0].Hash)
+	assert.True(t, tx.Indexed)
+	assert.Equal(t, block.Number, tx.BlockNumber)
+	assert.Equal(t, []string{wallet1PublicAddress}, tx.WatchedAddresses)
+}
+
+func TestAddressesAreChecksummed(t *testing.T) {
+	handler, client := setupFakeServer(t)
+
+	lowercase := strings.ToLower(wallet1PublicAddress)
+	subscribeAddress(t, handler, lowercase)
+
+	block := client.AppendBlock(fakeclient.NewTransaction(wallet1PublicAddress, wallet2PublicAddress, big.NewInt(1000)))
+	require.Eventually(t, func() bool {
+		return getCurrentBlock(t, handler) >= block.Number
+	}, fakeWaitTimeout, fakeIndexInterval)
+
+	for _, address := range []string{lowercase, wallet1PublicAddress} {
+		txs := getTransactions(t, handler, address, "")
+		require.Len(t, txs, 1, address)
+		assert.Equal(t, block.Transactions[0].Hash, txs[0].Hash)
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/public/api/v1/address/subscriptions", nil)
+	handler.ServeHTTP(rec, req)
+	var page apiResponse[bcparser.SubscriptionsPage]
+	parseApiResponse(t, rec, &page)
+	require.Len(t, page.Result.Subscriptions, 1)
+	assert.Equal(t, wallet1PublicAddress, page.Result.Subscriptions[0].Address)
+
+	body, err := json.Marshal(subscribeRequestBody{Address: lowercase})
+	require.NoError(t, err)
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest("DELETE", "/public/api/v1/address/unsubscribe", bytes.NewReader(body))
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAmountUnits(t *testing.T) {
+	handler, client := setupFakeServer(t)
+
+	subscribeAddress(t, handler, wallet1PublicAddress)
+
+	amount, _ := new(big.Int).SetString("1500000000000000000", 10)
+	block := client.AppendBlock(fakeclient.NewTransaction(wallet1PublicAddress, wallet2PublicAddress, amount))
+	require.Eventually(t, func() bool {
+		return getCurrentBlock(t, handler) >= block.Number
+	}, fakeWaitTimeout, fakeIndexInterval)
+
+	tests := []struct {
+		query     string
+		unit      string
+		formatted string
+	}{
+		{"", "ether", "1.5"},
+		{"unit=gwei", "gwei", "1500000000"},
+		{"unit=wei", "wei", "1500000000000000000"},
+	}
+	for _, test := range tests {
+		txs := getTransactions(t, handler, wallet1PublicAddress, test.query)
+		require.Len(t, txs, 1)
+		assert.Equal(t, "1500000000000000000", txs[0].Amount)
+		assert.Equal(t, test.formatted, txs[0].FormattedAmount)
+		assert.Equal(t, test.unit, txs[0].Unit)
+	}
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/public/api/v1/address/"+wallet1PublicAddress+"/stats?unit=gwei", nil)
+	require.NoError(t, err)
+	handler.ServeHTTP(rec, req)
+
+	var res apiResponse[statsResponse]
+	parseApiResponse(t, rec, &res)
+	assert.Equal(t, "1500000000000000000", res.Result.Stats.TotalSent)
+	assert.Equal(t, "1500000000", res.Result.Stats.FormattedTotalSent)
+	assert.Equal(t, "0", res.Result.Stats.FormattedTotalReceived)
+
+	rec = httptest.NewRecorder()
+	req, err = http.NewRequest("GET", "/public/api/v1/address/"+wallet1PublicAddress+"/transactions?unit=finney", nil)
+	require.NoError(t, err)
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestTransactionsTimeRange(t *testing.T) {
+	handler, client := setupFakeServer(t)
+
+	subscribeAddress(t, handler, wallet1PublicAddress)
+
+	blocks := make([]bcclient.Block, 0, 3)
+	for range 3 {
+		blocks = append(blocks, client.AppendBlock(fakeclient.NewTransaction(wallet1PublicAddress, wallet2PublicAddress, big.NewInt(1000))))
+	}
+	require.Eventually(t, func() bool {
+		return getCurrentBlock(t, handler) >= blocks[2].Number
+	}, fakeWaitTimeout, fakeIndexInterval)
+
+	txs := getTransactions(t, handler, wallet1PublicAddress, "")
+	require.Len(t, txs, 3)
+	for i, tx := range txs {
+		// transactions are stamped with the time of their block.
+		assert.True(t, blocks[i].Timestamp.Equal(tx.CreatedAt))
+	}
+
+	middle := blocks[1].Timestamp.Format(time.RFC3339)
+	txs = getTransactions(t, handler, wallet1PublicAddress, "from="+middle+"&to="+middle)
+	require.Len(t, txs, 1)
+	assert.Equal(t, blocks[1].Transactions[0].Hash, txs[0].Hash)
+
+	txs = getTransactions(t, handler, wallet1PublicAddress, "from="+middle)
+	assert.Len(t, txs, 2)
+
+	for _, query := range []string{"from=" + middle + "&to=" + blocks[0].Timestamp.Format(time.RFC3339), "from=yesterday"} {
+		rec := httptest.NewRecorder()
+		req, err := http.NewRequest("GET", "/public/api/v1/address/"+wallet1PublicAddress+"/transactions?"+query, nil)
+		require.NoError(t, err)
+		handler.ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+type subscriptionResponse struct {
+	Subscription bcparser.Subscription `json:"subscription"`
+}
+
+func TestSubscriptionMetadata(t *testing.T) {
+	handler, client := setupFakeServer(t)
+
+	// responses are parsed into a new value, Since empty fields are omitted.
+	writeSubscription := func(method string, path string, body gin.H) bcparser.Subscription {
+		var res apiResponse[subscriptionResponse]
+		parseApiResponse(t, apiRequest(t, handler, method, path, "", body), &res)
+
+		return res.Result.Subscription
+	}
+
+	sub := writeSubscription("POST", "/public/api/v1/address/subscribe", gin.H{
+		"address": wallet1PublicAddress,
+		"label":   "treasury",
+		"tags":    []string{"cold", "eu"},
+		"ownerId": "customer-1",
+	})
+	assert.Equal(t, "treasury", sub.Label)
+	assert.Equal(t, []string{"cold", "eu"}, sub.Tags)
+	assert.Equal(t, "customer-1", sub.OwnerID)
+	assert.False(t, sub.CreatedAt.IsZero())
+	subscribeAddress(t, handler, wallet2PublicAddress)
+
+	// fields which are not sent are left untouched.
+	path := "/public/api/v1/address/" + wallet1PublicAddress
+	sub = writeSubscription("PATCH", path, gin.H{"label": "vault"})
+	assert.Equal(t, "vault", sub.Label)
+	assert.Equal(t, []string{"cold", "eu"}, sub.Tags)
+	assert.Equal(t, "customer-1", sub.OwnerID)
+
+	sub = writeSubscription("PATCH", path, gin.H{"tags": []string{"hot"}, "ownerId": ""})
+	assert.Equal(t, "vault", sub.Label)
+	assert.Equal(t, []string{"hot"}, sub.Tags)
+	assert.Empty(t, sub.OwnerID)
+
+	var page apiResponse[bcparser.SubscriptionsPage]
+	parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/address/subscriptions?tag=hot", "", nil), &page)
+	assert.Equal(t, 1, page.Result.Total)
+	require.Len(t, page.Result.Subscriptions, 1)
+	assert.Equal(t, wallet1PublicAddress, page.Result.Subscriptions[0].Address)
+	assert.Equal(t, "vault", page.Result.Subscriptions[0].Label)
+	assert.Equal(t, []string{"hot"}, page.Result.Subscriptions[0].Tags)
+
+	// the metadata is attached to the transactions of the subscription.
+	block := client.AppendBlock(fakeclient.NewTransaction(wallet1PublicAddress, wallet3PublicAddress, big.NewInt(1)))
+	require.Eventually(t, func() bool {
+		return getCurrentBlock(t, handler) >= block.Number
+	}, fakeWaitTimeout, fakeIndexInterval)
+	tx := getTransaction(t, handler, block.Transactions[0].Hash)
+	require.Len(t, tx.Subscriptions, 1)
+	assert.Equal(t, "vault", tx.Subscriptions[0].Label)
+
+	rec := apiRequest(t, handler, "PATCH", path, "", gin.H{"label": strings.Repeat("a", 257)})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = apiRequest(t, handler, "PATCH", "/public/api/v1/address/"+wallet3PublicAddress, "", gin.H{"label": "unknown"})
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+type bulkResponse struct {
+	Summary address.BulkSummary      `json:"summary"`
+	Results []address.BulkItemResult `json:"results"`
+}
+
+// bulkRequest sends a bulk request with a raw payload of the given content type.
+func bulkRequest(t *testing.T, handler http.Handler, method string, contentType string, body string) bulkResponse {
+	path := "/public/api/v1/address/subscribe/bulk"
+	if method == "DELETE" {
+		path = "/public/api/v1/address/unsubscribe/bulk"
+	}
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(method, path, strings.NewReader(body))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", contentType)
+	handler.ServeHTTP(rec, req)
+
+	var res apiResponse[bulkResponse]
+	parseApiResponse(t, rec, &res)
+
+	return res.Result
+}
+
+func TestBulkSubscribeAndUnsubscribe(t *testing.T) {
+	handler, _ := setupFakeServer(t)
+
+	res := bulkRequest(t, handler, "POST", "application/json",
+		`{"addresses": ["`+strings.ToLower(wallet1PublicAddress)+`", "not-an-address"], "label": "treasury"}`)
+	assert.Equal(t, address.BulkSummary{Total: 2, Succeeded: 1, Invalid: 1}, res.Summary)
+	require.Len(t, res.Results, 2)
+	assert.Equal(t, address.BulkItemResult{Address: wallet1PublicAddress, Status: address.StatusSubscribed}, res.Results[0])
+	assert.Equal(t, "not-an-address", res.Results[1].Address)
+	assert.Equal(t, address.StatusInvalid, res.Results[1].Status)
+	assert.NotEmpty(t, res.Results[1].Error)
+
+	// already subscribed and malformed items are reported without failing the rest of the request.
+	res = bulkRequest(t, handler, "POST", "application/x-ndjson", strings.Join([]string{
+		`{"address": "` + wallet1PublicAddress + `"}`,
+		`{"address": "` + wallet2PublicAddress + `", "label": "hot"}`,
+		`garbage`,
+	}, "\n"))
+	assert.Equal(t, address.BulkSummary{Total: 3, Succeeded: 1, Skipped: 1, Invalid: 1}, res.Summary)
+	require.Len(t, res.Results, 3)
+	assert.Equal(t, address.StatusAlreadySubscribed, res.Results[0].Status)
+	assert.Equal(t, address.StatusSubscribed, res.Results[1].Status)
+	assert.Equal(t, address.StatusInvalid, res.Results[2].Status)
+
+	res = bulkRequest(t, handler, "POST", "text/csv", "address,label\n"+wallet3PublicAddress+",cold\n")
+	assert.Equal(t, address.BulkSummary{Total: 1, Succeeded: 1}, res.Summary)
+
+	var subscriptions apiResponse[bcparser.SubscriptionsPage]
+	parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/address/subscriptions", "", nil), &subscriptions)
+	labels := make(map[string]string)
+	for _, sub := range subscriptions.Result.Subscriptions {
+		labels[sub.Address] = sub.Label
+	}
+	assert.Equal(t, map[string]string{
+		wallet1PublicAddress: "treasury",
+		wallet2PublicAddress: "hot",
+		wallet3PublicAddress: "cold",
+	}, labels)
+
+	res = bulkRequest(t, handler, "DELETE", "text/csv", wallet1PublicAddress+"\n"+wallet3PublicAddress+"\n")
+	assert.Equal(t, address.BulkSummary{Total: 2, Succeeded: 2}, res.Summary)
+
+	res = bulkRequest(t, handler, "DELETE", "application/json",
+		`{"addresses": ["`+wallet1PublicAddress+`", "`+wallet2PublicAddress+`"]}`)
+	assert.Equal(t, address.BulkSummary{Total: 2, Succeeded: 1, Skipped: 1}, res.Summary)
+	require.Len(t, res.Results, 2)
+	assert.Equal(t, address.StatusNotSubscribed, res.Results[0].Status)
+	assert.Equal(t, address.StatusUnsubscribed, res.Results[1].Status)
+
+	parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/address/subscriptions", "", nil), &subscriptions)
+	assert.Zero(t, subscriptions.Result.Total)
+
+	// requests without any address are rejected as a whole.
+	rec := apiRequest(t, handler, "POST", "/public/api/v1/address/subscribe/bulk", "", gin.H{"addresses": []string{}})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func exportTransactions(t *testing.T, handler http.Handler, address string, query string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest("GET", "/public/api/v1/address/"+address+"/transactions/export?"+query, nil)
+	if err != nil {
+		panic(err)
+	}
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func TestExportTransactions(t *testing.T) {
+	handler, client := setupFakeServer(t)
+
+	subscribeAddress(t, handler, wallet1PublicAddress)
+	subscribeAddress(t, handler, wallet2PublicAddress)
+
+	oneAndHalfEther, _ := new(big.Int).SetString("1500000000000000000", 10)
+	first := client.AppendBlock(fakeclient.NewTransaction(wallet1PublicAddress, wallet3PublicAddress, oneAndHalfEther))
+	second := client.AppendBlock(fakeclient.NewTransaction(wallet3PublicAddress, wallet2PublicAddress, big.NewInt(1)))
+	require.Eventually(t, func() bool {
+		return getCurrentBlock(t, handler) >= second.Number
+	}, fakeWaitTimeout, fakeIndexInterval)
+
+	t.Run("csv", func(t *testing.T) {
+		rec := exportTransactions(t, handler, wallet1PublicAddress, "addresses="+wallet2PublicAddress)
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv", rec.Header().Get("Content-Type"))
+
+		records, err := csv.NewReader(rec.Body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		assert.Equal(t, "address", records[0][0])
+		assert.Equal(t, []string{wallet1PublicAddress, first.Transactions[0].Hash}, records[1][:2])
+		assert.Equal(t, []string{"1500000000000000000", "1.5"}, records[1][6:8])
+		assert.Equal(t, []string{wallet2PublicAddress, second.Transactions[0].Hash}, records[2][:2])
+		assert.Equal(t, []string{"1", "0.000000000000000001"}, records[2][6:8])
+	})
+
+	t.Run("jsonl with block range", func(t *testing.T) {
+		query := "format=jsonl&addresses=" + wallet2PublicAddress + "&fromBlock=" + strconv.FormatUint(second.Number, 10)
+		rec := exportTransactions(t, handler, wallet1PublicAddress, query)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var rows []exportedTransaction
+		scanner := bufio.NewScanner(rec.Body)
+		for scanner.Scan() {
+			var row exportedTransaction
+			require.NoError(t, json.Unmarshal(scanner.Bytes(), &row))
+			rows = append(rows, row)
+		}
+		require.Len(t, rows, 1)
+		assert.Equal(t, second.Transactions[0].Hash, rows[0].Hash)
+		assert.Equal(t, second.Number, rows[0].BlockNumber)
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		rec := exportTransactions(t, handler, wallet1PublicAddress, "format=parquet")
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		body := rec.Body.Bytes()
+		rows, err := parquet.Read[exportedTransaction](bytes.NewReader(body), int64(len(body)))
+		require.NoError(t, err)
+		require.Len(t, rows, 1)
+		assert.Equal(t, first.Transactions[0].Hash, rows[0].Hash)
+		assert.Equal(t, "1.5", rows[0].AmountEther)
+		assert.Equal(t, first.Transactions[0].CreatedAt.UnixMilli(), rows[0].CreatedAt.UnixMilli())
+	})
+
+	t.Run("unsubscribed address", func(t *testing.T) {
+		rec := exportTransactions(t, handler, wallet1PublicAddress, "addresses="+wallet3PublicAddress)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+
+	t.Run("invalid block range", func(t *testing.T) {
+		rec := exportTransactions(t, handler, wallet1PublicAddress, "fromBlock=2&toBlock=1")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
+
+type indexerInfoResponse struct {
+	Indexer admin.IndexerInfoModel `json:"indexer"`
+}
+
+const (
+	adminAPIKey  = "admin-key"
+	readerAPIKey = "reader-key"
+)
+
+func setupAdminServer(t *testing.T) (http.Handler, *fakeclient.Client) {
+	t.Helper()
+
+	return setupAuthServer(t,
+		controller.APIKey{ID: "admin", Hash: controller.HashAPIKey(adminAPIKey), Scopes: []string{scopes.Admin}},
+		controller.APIKey{ID: "reader", Hash: controller.HashAPIKey(readerAPIKey), Scopes: []string{scopes.ReadBlocks}},
+	)
+}
+
+// setupAuthServer creates a server which authenticates requests with the given keys.
+func setupAuthServer(t *testing.T, keys ...controller.APIKey) (http.Handler, *fakeclient.Client) {
+	t.Helper()
+	useFreshMetricsRegistry(t)
+
+	client := fakeclient.New()
+	client.AppendBlocks(3)
+
+	logger := zap.NewNop()
+	parser := bccparser.New(logger, client, bccparser.Options{
+		IndexInterval:            fakeIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+	})
+	t.Cleanup(parser.Stop)
+
+	server, err := NewServer(logger, Options{
+		BlockchainParser: parser,
+		Indexer:          parser,
+		APIKeyStore:      controller.NewMemoryAPIKeyStore(keys...),
+	})
+	require.NoError(t, err)
+	<-parser.Ready()
+
+	return server.Handler, client
+}
+
+// apiRequest sends a request with a json body to path, It's authenticated with apiKey unless it's empty.
+func apiRequest(t *testing.T, handler http.Handler, method string, path string, apiKey string, body any) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(encoded)
+	}
+
+	rec := httptest.NewRecorder()
+	req, err := http.NewRequest(method, path, reader)
+	require.NoError(t, err)
+	if apiKey != "" {
+		req.Header.Set(controller.APIKeyHeader, apiKey)
+	}
+	handler.ServeHTTP(rec, req)
+
+	return rec
+}
+
+func adminRequest(t *testing.T, handler http.Handler, method string, path string, apiKey string, body any) *httptest.ResponseRecorder {
+	return apiRequest(t, handler, method, "/admin/indexer"+path, apiKey, body)
+}
+
+func getIndexerInfo(t *testing.T, rec *httptest.ResponseRecorder) admin.IndexerInfoModel {
+	var res apiResponse[indexerInfoResponse]
+	parseApiResponse(t, rec, &res)
+
+	return res.Result.Indexer
+}
+
+func TestAdminRequiresAdminScope(t *testing.T) {
+	handler, _ := setupAdminServer(t)
+
+	assert.Equal(t, http.StatusUnauthorized, adminRequest(t, handler, "GET", "", "", nil).Code)
+	assert.Equal(t, http.StatusForbidden, adminRequest(t, handler, "POST", "/pause", readerAPIKey, nil).Code)
+	assert.Equal(t, http.StatusOK, adminRequest(t, handler, "GET", "", adminAPIKey, nil).Code)
+}
+
+func TestPublicRoutesRequireScopes(t *testing.T) {
+	const (
+		blocksKey       = "blocks-key"
+		transactionsKey = "transactions-key"
+		writerKey       = "writer-key"
+		wildcardKey     = "wildcard-key"
+	)
+	handler, _ := setupAuthServer(t,
+		controller.APIKey{ID: "blocks", Hash: controller.HashAPIKey(blocksKey), Scopes: []string{scopes.ReadBlocks}, TenantID: "team"},
+		controller.APIKey{ID: "transactions", Hash: controller.HashAPIKey(transactionsKey), Scopes: []string{scopes.ReadTransactions}, TenantID: "team"},
+		controller.APIKey{ID: "writer", Hash: controller.HashAPIKey(writerKey), Scopes: []string{scopes.WriteSubscriptions}, TenantID: "team"},
+		controller.APIKey{ID: "wildcard", Hash: controller.HashAPIKey(wildcardKey), Scopes: []string{controller.WildcardScope}, TenantID: "team"},
+	)
+
+	const subscribePath = "/public/api/v1/address/subscribe"
+	for _, apiKey := range []string{"", "unknown-key"} {
+		rec := apiRequest(t, handler, "POST", subscribePath, apiKey, subscribeRequestBody{Address: wallet1PublicAddress})
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+	for _, apiKey := range []string{blocksKey, transactionsKey} {
+		rec := apiRequest(t, handler, "POST", subscribePath, apiKey, subscribeRequestBody{Address: wallet1PublicAddress})
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	}
+	assert.Equal(t, http.StatusOK, apiRequest(t, handler, "POST", subscribePath, wildcardKey, subscribeRequestBody{Address: wallet2PublicAddress}).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(t, handler, "POST", subscribePath, writerKey, subscribeRequestBody{Address: wallet1PublicAddress}).Code)
+
+	// the balance is fetched in the background after subscribing.
+	balancePath := "/public/api/v1/address/" + wallet1PublicAddress + "/balance"
+	require.Eventually(t, func() bool {
+		return apiRequest(t, handler, "GET", balancePath, wildcardKey, nil).Code == http.StatusOK
+	}, fakeWaitTimeout, fakeIndexInterval)
+
+	routes := []struct {
+		path string
+		// apiKey is the only key, Other than the wildcard key, which has the scope of the route.
+		apiKey string
+	}{
+		{"/public/api/v1/block/current", blocksKey},
+		{"/public/api/v1/block/status", blocksKey},
+		{"/public/api/v1/address/" + wallet1PublicAddress + "/transactions", transactionsKey},
+		{balancePath, transactionsKey},
+		{"/public/api/v1/address/" + wallet1PublicAddress + "/stats", transactionsKey},
+		{"/public/api/v1/address/subscriptions", wildcardKey},
+	}
+
+	for _, route := range routes {
+		assert.Equal(t, http.StatusUnauthorized, apiRequest(t, handler, "GET", route.path, "", nil).Code, route.path)
+		assert.Equal(t, http.StatusUnauthorized, apiRequest(t, handler, "GET", route.path, "unknown-key", nil).Code, route.path)
+		for _, apiKey := range []string{blocksKey, transactionsKey, writerKey} {
+			if apiKey != route.apiKey {
+				assert.Equal(t, http.StatusForbidden, apiRequest(t, handler, "GET", route.path, apiKey, nil).Code, route.path)
+			}
+		}
+		assert.Equal(t, http.StatusOK, apiRequest(t, handler, "GET", route.path, route.apiKey, nil).Code, route.path)
+		assert.Equal(t, http.StatusOK, apiRequest(t, handler, "GET", route.path, wildcardKey, nil).Code, route.path)
+	}
+
+	// keys can also be sent as a bearer token.
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/public/api/v1/block/current", nil)
+	req.Header.Set("Authorization", "Bearer "+blocksKey)
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestTenantsAreIsolated(t *testing.T) {
+	const (
+		aliceKey = "alice-key"
+		carolKey = "carol-key"
+		bobKey   = "bob-key"
+	)
+	handler, client := setupAuthServer(t,
+		controller.APIKey{ID: "alice", Hash: controller.HashAPIKey(aliceKey), Scopes: []string{controller.WildcardScope}, TenantID: "acme"},
+		controller.APIKey{ID: "carol", Hash: controller.HashAPIKey(carolKey), Scopes: []string{controller.WildcardScope}, TenantID: "acme"},
+		controller.APIKey{ID: "bob", Hash: controller.HashAPIKey(bobKey), Scopes: []string{controller.WildcardScope}},
+	)
+
+	const subscribePath = "/public/api/v1/address/subscribe"
+	assert.Equal(t, http.StatusOK, apiRequest(t, handler, "POST", subscribePath, aliceKey, subscribeRequestBody{Address: wallet1PublicAddress}).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(t, handler, "POST", subscribePath, bobKey, subscribeRequestBody{Address: wallet2PublicAddress}).Code)
+
+	amount := big.NewInt(1000)
+	block := client.AppendBlock(fakeclient.NewTransaction(wallet1PublicAddress, wallet2PublicAddress, amount))
+	require.Eventually(t, func() bool {
+		var res apiResponse[currentBlockResponse]
+		parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/block/current", aliceKey, nil), &res)
+
+		return res.Result.LastIndexedBlock >= block.Number
+	}, fakeWaitTimeout, fakeIndexInterval)
+
+	getSubscriptions := func(apiKey string) []string {
+		var res apiResponse[bcparser.SubscriptionsPage]
+		parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/address/subscriptions", apiKey, nil), &res)
+		addresses := make([]string, 0, len(res.Result.Subscriptions))
+		for _, sub := range res.Result.Subscriptions {
+			addresses = append(addresses, sub.Address)
+		}
+
+		return addresses
+	}
+
+	// keys of the same tenant share their subscriptions.
+	assert.Equal(t, []string{wallet1PublicAddress}, getSubscriptions(aliceKey))
+	assert.Equal(t, []string{wallet1PublicAddress}, getSubscriptions(carolKey))
+	assert.Equal(t, []string{wallet2PublicAddress}, getSubscriptions(bobKey))
+
+	for _, path := range []string{"/transactions", "/balance", "/stats"} {
+		rec := apiRequest(t, handler, "GET", "/public/api/v1/address/"+wallet1PublicAddress+path, bobKey, nil)
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+	assert.Equal(t, http.StatusNotFound, apiRequest(t, handler, "DELETE", "/public/api/v1/address/unsubscribe", bobKey, subscribeRequestBody{Address: wallet1PublicAddress}).Code)
+
+	var txs apiResponse[transactionsResponse]
+	parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/address/"+wallet1PublicAddress+"/transactions", carolKey, nil), &txs)
+	require.Len(t, txs.Result.Transactions, 1)
+	assert.Equal(t, block.Transactions[0].Hash, txs.Result.Transactions[0].Hash)
+
+	var stats apiResponse[statsResponse]
+	parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/address/"+wallet2PublicAddress+"/stats", bobKey, nil), &stats)
+	assert.Equal(t, uint64(1), stats.Result.Stats.TxCountIn)
+	assert.Equal(t, amount.String(), stats.Result.Stats.TotalReceived)
+
+	// a transaction only reveals the subscriptions of the tenant which requested it.
+	var tx apiResponse[transactionResponse]
+	parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/tx/"+block.Transactions[0].Hash, aliceKey, nil), &tx)
+	assert.Equal(t, []string{wallet1PublicAddress}, tx.Result.Transaction.WatchedAddresses)
+	parseApiResponse(t, apiRequest(t, handler, "GET", "/public/api/v1/tx/"+block.Transactions[0].Hash, bobKey, nil), &tx)
+	assert.Equal(t, []string{wallet2PublicAddress}, tx.Result.Transaction.WatchedAddresses)
+	require.Len(t, tx.Result.Transaction.Subscriptions, 1)
+	assert.Equal(t, wallet2PublicAddress, tx.Result.Transaction.Subscriptions[0].Address)
+
+	// unsubscribing does not affect the other tenants which have subscribed to the same address.
+	assert.Equal(t, http.StatusOK, apiRequest(t, handler, "POST", subscribePath, bobKey, subscribeRequestBody{Address: wallet1PublicAddress}).Code)
+	assert.Equal(t, http.StatusOK, apiRequest(t, handler, "DELETE", "/public/api/v1/address/unsubscribe", bobKey, subscribeRequestBody{Address: wallet1PublicAddress}).Code)
+	assert.Equal(t, []string{wallet1PublicAddress}, getSubscriptions(aliceKey))
+}
+
+func TestAdminPauseAndResume(t *testing.T) {
+	handler, client := setupAdminServer(t)
+
+	info := getIndexerInfo(t, adminRequest(t, handler, "POST", "/pause", adminAPIKey, nil))
+	assert.True(t, info.Paused)
+	assert.Equal(t, bcparser.IndexerStatePaused, info.State)
+
+	head := client.AppendBlocks(2)
+	time.Sleep(10 * fakeIndexInterval)
+	info = getIndexerInfo(t, adminRequest(t, handler, "GET", "", adminAPIKey, nil))
+	assert.Less(t, info.LastIndexedBlock, head)
+
+	info = getIndexerInfo(t, adminRequest(t, handler, "POST", "/resume", adminAPIKey, nil))
+	assert.False(t, info.Paused)
+	require.Eventually(t, func() bool {
+		return getIndexerInfo(t, adminRequest(t, handler, "GET", "", adminAPIKey, nil)).LastIndexedBlock >= head
+	}, fakeWaitTimeout, fakeIndexInterval)
+}
+
+func TestAdminSetIndexInterval(t *testing.T) {
+	handler, _ := setupAdminServer(t)
+
+	info := getIndexerInfo(t, adminRequest(t, handler, "PUT", "/interval", adminAPIKey, gin.H{"interval": "20ms"}))
+	assert.Equal(t, "20ms", info.IndexInterval)
+
+	for _, interval := range []string{"-1s", "soon"} {
+		rec := adminRequest(t, handler, "PUT", "/interval", adminAPIKey, gin.H{"interval": interval})
+		assert.Equal(t, http.StatusBadRequest, rec.Code, interval)
+	}
+}
+
+func TestAdminReindex(t *testing.T) {
+	handler, client := setupAdminServer(t)
+
+	block := client.AppendBlock(fakeclient.NewTransaction(wallet1PublicAddress, wallet2PublicAddress, big.NewInt(1)))
+	require.Eventually(t, func() bool {
+		return getIndexerInfo(t, adminRequest(t, handler, "GET", "", adminAPIKey, nil)).LastIndexedBlock >= block.Number
+	}, fakeWaitTimeout, fakeIndexInterval)
+
+	rec := adminRequest(t, handler, "POST", "/reindex", adminAPIKey, gin.H{"from": block.Number, "to": block.Number + 1})
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	info := getIndexerInfo(t, adminRequest(t, handler, "POST", "/reindex", adminAPIKey, gin.H{"from": block.Number, "to": block.Number}))
+	require.NotNil(t, info.LastReindex)
+	assert.Equal(t, block.Number, info.LastReindex.From)
+
+	require.Eventually(t, func() bool {
+		status := getIndexerInfo(t, adminRequest(t, handler, "GET", "", adminAPIKey, nil)).LastReindex
+
+		return !status.Running && status.Error == ""
+	}, fakeWaitTimeout, fakeIndexInterval)
+}
+
+func setupRateLimitedServer(t *testing.T, trustedProxies []string) http.Handler {
+	t.Helper()
+	useFreshMetricsRegistry(t)
+
+	client := fakeclient.New()
+	logger := zap.NewNop()
+	parser := bccparser.New(logger, client, bccparser.Options{
+		IndexInterval:            fakeIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+	})
+	t.Cleanup(parser.Stop)
+
+	server, err := NewServer(logger, Options{
+		Controller: controller.Options{
+			TrustedProxies: trustedProxies,
+		},
+		BlockchainParser: parser,
+		PublicRateLimits: controller.GroupRateLimits{
+			PerIP: controller.RateLimit{RequestsPerSecond: 0.001, Burst: 1},
+		},
+	})
+	require.NoError(t, err)
+
+	return server.Handler
+}
+
+// getCurrentBlockFrom requests the current block through a proxy which has set X-Forwarded-For to clientIP.
+func getCurrentBlockFrom(handler http.Handler, clientIP string) int {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/public/api/v1/block/current", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	req.Header.Set("X-Forwarded-For", clientIP)
+	handler.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestRateLimitIgnoresForwardedForOfUntrustedPeers(t *testing.T) {
+	handler := setupRateLimitedServer(t, nil)
+
+	assert.Equal(t, http.StatusOK, getCurrentBlockFrom(handler, "198.51.100.1"))
+	assert.Equal(t, http.StatusTooManyRequests, getCurrentBlockFrom(handler, "198.51.100.2"))
+}
+
+func TestRateLimitUsesForwardedForOfTrustedProxies(t *testing.T) {
+	handler := setupRateLimitedServer(t, []string{"192.0.2.0/24"})
+
+	assert.Equal(t, http.StatusOK, getCurrentBlockFrom(handler, "198.51.100.1"))
+	assert.Equal(t, http.StatusOK, getCurrentBlockFrom(handler, "198.51.100.2"))
+	assert.Equal(t, http.StatusTooManyRequests, getCurrentBlockFrom(handler, "198.51.100.1"))
+}
+
+type healthReportResponse = apiResponse[controller.HealthReport]
+
+func TestHealthEndpoints(t *testing.T) {
+	useFreshMetricsRegistry(t)
+
+	logger := zap.NewNop()
+	parser := bccparser.New(logger, fakeclient.New(), bccparser.Options{IndexInterval: fakeIndexInterval})
+	t.Cleanup(parser.Stop)
+
+	var rpcDown atomic.Bool
+	rpcDown.Store(true)
+	registry := controller.NewHealthRegistry()
+	registry.AddReadinessCheck("parser", parser.CheckReady)
+	registry.AddReadinessCheck("rpc", func(ctx context.Context) error {
+		if rpcDown.Load() {
+			return errors.New("node is down")
+		}
+
+		return nil
+	})
+	registry.AddLivenessCheck("indexer", parser.CheckLive)
+
+	// health checks are served without authentication.
+	server, err := NewServer(logger, Options{
+		BlockchainParser: parser,
+		APIKeyStore:      controller.NewMemoryAPIKeyStore(),
+		HealthRegistry:   registry,
+	})
+	require.NoError(t, err)
+	<-parser.Ready()
+
+	rec := apiRequest(t, server.Handler, "GET", "/-/ready", "", nil)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	var res healthReportResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.False(t, res.Success)
+	assert.False(t, res.Result.Healthy)
+	assert.Equal(t, map[string]controller.HealthCheckResult{
+		"parser": {Healthy: true},
+		"rpc":    {Healthy: false, Error: "node is down"},
+	}, res.Result.Checks)
+
+	rpcDown.Store(false)
+	res = healthReportResponse{}
+	parseApiResponse(t, apiRequest(t, server.Handler, "GET", "/-/ready", "", nil), &res)
+	assert.True(t, res.Result.Healthy)
+
+	res = healthReportResponse{}
+	parseApiResponse(t, apiRequest(t, server.Handler, "GET", "/-/live", "", nil), &res)
+	assert.Equal(t, map[string]controller.HealthCheckResult{"indexer": {Healthy: true}}, res.Result.Checks)
+
+	registry.AddLivenessCheck("indexer", func(ctx context.Context) error {
+		return errors.New("indexer is stuck")
+	})
+	assert.Equal(t, http.StatusServiceUnavailable, apiRequest(t, server.Handler, "GET", "/-/live", "", nil).Code)
+}
+
+// panickingParser panics when the current block is requested.
+type panickingParser struct {
+	bcparser.Parser
+}
+
+func (p panickingParser) CurrentBlockNumber() uint64 {
+	panic("boom")
+}
+
+func TestHandlerPanicsAreRecovered(t *testing.T) {
+	useFreshMetricsRegistry(t)
+
+	parser := bccparser.New(zap.NewNop(), fakeclient.New(), bccparser.Options{IndexInterval: fakeIndexInterval})
+	t.Cleanup(parser.Stop)
+	server, err := NewServer(zap.NewNop(), Options{
+		BlockchainParser: panickingParser{Parser: parser},
+	})
+	require.NoError(t, err)
+
+	rec := apiRequest(t, server.Handler, "GET", "/public/api/v1/block/current", "", nil)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	var res apiResponse[struct {
+		Type string `json:"type"`
+	}]
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	assert.False(t, res.Success)
+	assert.Equal(t, "InternalError", res.Result.Type)
+
+	families, err := prometheus.DefaultGatherer.Gather()
+	require.NoError(t, err)
+	var panics float64
+	for _, family := range families {
+		if strings.HasSuffix(family.GetName(), controller.PanicsMetric) {
+			for _, metric := range family.GetMetric() {
+				panics += metric.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, float64(1), panics)
+
+	// the server keeps serving the other requests.
+	assert.Equal(t, http.StatusOK, apiRequest(t, server.Handler, "GET", "/public/api/v1/block/status", "", nil).Code)
+}
+
+func TestRequestsContinueIncomingTraces(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+	})
+	_, err := tracing.Setup(context.Background(), tracing.Options{})
+	require.NoError(t, err)
+
+	handler, _ := setupFakeServer(t)
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/public/api/v1/block/current", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var serverSpans []sdktrace.ReadOnlySpan
+	for _, span := range recorder.Ended() {
+		if span.SpanKind() == trace.SpanKindServer {
+			serverSpans = append(serverSpans, span)
+		}
+	}
+	require.Len(t, serverSpans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", serverSpans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", serverSpans[0].Parent().SpanID().String())
+	assert.True(t, serverSpans[0].Parent().IsRemote())
+}
@@ -1,8 +1,12 @@
 package address
 
 import (
+	"blockbook/internal/api/scopes"
+	"blockbook/internal/api/views"
+	"blockbook/pkg/bcclient"
 	"blockbook/pkg/bcparser"
 	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,27 +24,91 @@ func (a *Address) PathPrefix() string {
 }
 
 func (a *Address) RegisterHandlers(engine *gin.RouterGroup) {
-	engine.GET("/:address/transactions", a.transactions)
-	engine.POST("/subscribe", a.subscribe)
-	engine.DELETE("/unsubscribe", a.unsubscribe)
+	readSubscriptions := controller.RequireScopes(scopes.ReadSubscriptions)
+	readTransactions := controller.RequireScopes(scopes.ReadTransactions)
+	writeSubscriptions := controller.RequireScopes(scopes.WriteSubscriptions)
+
+	engine.GET("/subscriptions", readSubscriptions, a.subscriptions)
+	engine.GET("/:address/transactions", readTransactions, a.transactions)
+	engine.GET("/:address/transactions/export", readTransactions, a.export)
+	engine.GET("/:address/balance", readTransactions, a.balance)
+	engine.GET("/:address/stats", readTransactions, a.stats)
+	engine.POST("/subscribe", writeSubscriptions, a.subscribe)
+	engine.POST("/subscribe/bulk", writeSubscriptions, a.bulkSubscribe)
+	engine.DELETE("/unsubscribe", writeSubscriptions, a.unsubscribe)
+	engine.DELETE("/unsubscribe/bulk", writeSubscriptions, a.bulkUnsubscribe)
+	engine.PATCH("/:address", writeSubscriptions, a.updateSubscription)
 }
 
 func (a *Address) subscribe(c *gin.Context) {
-	model, err := controller.BindBody[AddressModel](c)
+	model, err := controller.BindBody[SubscribeModel](c)
 	if err != nil {
 		controller.WriteError(err, c)
 
 		return
 	}
 
-	ok := a.parser.Subscribe(model.Address)
+	ok := a.parser.Subscribe(controller.GetTenant(c), model.toSubscription())
 	if !ok {
 		controller.WriteError(ErrAddressAlreadySubscribed, c)
 
 		return
 	}
 
-	controller.WriteSuccess(gin.H{}, c)
+	a.writeSubscription(model.Address, c)
+}
+
+func (a *Address) updateSubscription(c *gin.Context) {
+	uriModel, err := controller.BindUri[AddressModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	model, err := controller.BindBody[UpdateSubscriptionModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	_, err = a.parser.UpdateSubscription(controller.GetTenant(c), uriModel.Address, bcparser.SubscriptionUpdate{
+		Label:   model.Label,
+		Tags:    model.Tags,
+		OwnerID: model.OwnerID,
+	})
+	if err != nil {
+		writeParserError(err, c)
+
+		return
+	}
+
+	a.writeSubscription(uriModel.Address, c)
+}
+
+func (a *Address) subscriptions(c *gin.Context) {
+	model, err := controller.BindQuery[SubscriptionsQueryModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	controller.WriteSuccess(a.parser.Subscriptions(controller.GetTenant(c), model.toQuery()), c)
+}
+
+func (a *Address) writeSubscription(address string, c *gin.Context) {
+	sub, err := a.parser.Subscription(controller.GetTenant(c), address)
+	if err != nil {
+		writeParserError(err, c)
+
+		return
+	}
+
+	controller.WriteSuccess(gin.H{
+		"subscription": sub,
+	}, c)
 }
 
 func (a *Address) unsubscribe(c *gin.Context) {
@@ -51,7 +119,7 @@ func (a *Address) unsubscribe(c *gin.Context) {
 		return
 	}
 
-	ok := a.parser.Unsubscribe(model.Address)
+	ok := a.parser.Unsubscribe(controller.GetTenant(c), model.Address)
 	if !ok {
 		controller.WriteError(ErrAddressNotSubscribed, c)
 
@@ -69,18 +137,117 @@ func (a *Address) transactions(c *gin.Context) {
 		return
 	}
 
-	txs := a.parser.Transactions(model.Address)
+	sub, err := a.parser.Subscription(controller.GetTenant(c), model.Address)
+	if err != nil {
+		writeParserError(err, c)
+
+		return
+	}
+
+	query, err := controller.BindQuery[TransactionsQueryModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	if query.From != nil && query.To != nil && query.From.After(*query.To) {
+		controller.WriteError(ErrInvalidTimeRange, c)
+
+		return
+	}
+
+	amounts, err := views.NewAmounts(query.Unit)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	txs := a.parser.Transactions(controller.GetTenant(c), model.Address)
 	if txs == nil {
 		controller.WriteError(ErrAddressNotSubscribed, c)
 
 		return
 	}
 
+	filtered := make([]*bcclient.Transaction, 0, len(txs))
+	for _, tx := range txs {
+		if query.contains(tx.CreatedAt) {
+			filtered = append(filtered, tx)
+		}
+	}
+
+	controller.WriteSuccess(gin.H{
+		"subscription": sub,
+		"transactions": amounts.Transactions(filtered),
+	}, c)
+}
+
+func (a *Address) balance(c *gin.Context) {
+	model, err := controller.BindUri[AddressModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	amounts, err := views.BindAmounts(c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	balance, err := a.parser.Balance(controller.GetTenant(c), model.Address)
+	if err != nil {
+		writeParserError(err, c)
+
+		return
+	}
+
+	controller.WriteSuccess(amounts.Balance(balance), c)
+}
+
+func (a *Address) stats(c *gin.Context) {
+	model, err := controller.BindUri[AddressModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	amounts, err := views.BindAmounts(c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	stats, err := a.parser.Stats(controller.GetTenant(c), model.Address)
+	if err != nil {
+		writeParserError(err, c)
+
+		return
+	}
+
 	controller.WriteSuccess(gin.H{
-		"transactions": txs,
+		"stats": amounts.Stats(stats),
 	}, c)
 }
 
+// writeParserError converts the errors returned by the parser to their api counterparts.
+func writeParserError(err error, c *gin.Context) {
+	switch {
+	case errors.Is(err, bcparser.ErrAddressNotSubscribed):
+		controller.WriteError(ErrAddressNotSubscribed, c)
+	case errors.Is(err, bcparser.ErrBalanceNotAvailable):
+		controller.WriteError(ErrBalanceNotAvailable, c)
+	default:
+		controller.WriteError(err, c)
+	}
+}
+
 func New(parser bcparser.Parser) *Address {
 	return &Address{
 		parser: parser,
@@ -0,0 +1,276 @@
+package address
+
+import (
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+const (
+	// MaxBulkAddresses is the maximum number of addresses accepted in a single bulk request.
+	MaxBulkAddresses = 50000
+	// MaxBulkBodyBytes is the maximum size of a bulk request payload.
+	MaxBulkBodyBytes = 16 << 20
+
+	ndjsonContentType = "application/x-ndjson"
+	csvContentType    = "text/csv"
+	uploadFormField   = "file"
+
+	StatusSubscribed        = "subscribed"
+	StatusUnsubscribed      = "unsubscribed"
+	StatusAlreadySubscribed = "alreadySubscribed"
+	StatusNotSubscribed     = "notSubscribed"
+	StatusInvalid           = "invalid"
+)
+
+// bulkItem is a single subscription of a bulk request. err is set when the item could not be parsed or validated.
+type bulkItem struct {
+	model SubscribeModel
+	err   error
+}
+
+func (a *Address) bulkSubscribe(c *gin.Context) {
+	a.bulk(c, func(items []SubscribeModel) []bool {
+		subscriptions := make([]bcparser.Subscription, 0, len(items))
+		for _, item := range items {
+			subscriptions = append(subscriptions, item.toSubscription())
+		}
+
+		return a.parser.SubscribeMany(controller.GetTenant(c), subscriptions)
+	}, StatusSubscribed, StatusAlreadySubscribed)
+}
+
+func (a *Address) bulkUnsubscribe(c *gin.Context) {
+	a.bulk(c, func(items []SubscribeModel) []bool {
+		addresses := make([]string, 0, len(items))
+		for _, item := range items {
+			addresses = append(addresses, item.Address)
+		}
+
+		return a.parser.UnsubscribeMany(controller.GetTenant(c), addresses)
+	}, StatusUnsubscribed, StatusNotSubscribed)
+}
+
+// bulk validates the items of a bulk request, applies the valid ones using apply and writes the result of each item in
+// the response.
+func (a *Address) bulk(c *gin.Context, apply func(items []SubscribeModel) []bool, okStatus, failedStatus string) {
+	items, err := readBulkItems(c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	results := make([]BulkItemResult, len(items))
+	valid := make([]SubscribeModel, 0, len(items))
+	validIndexes := make([]int, 0, len(items))
+	for i, item := range items {
+		results[i].Address = item.model.Address
+		if item.err == nil {
+			item.err = validateItem(item.model)
+		}
+
+		if item.err != nil {
+			results[i].Status = StatusInvalid
+			results[i].Error = errorMessage(item.err)
+
+			continue
+		}
+
+		item.model.Normalize()
+		results[i].Address = item.model.Address
+		valid = append(valid, item.model)
+		validIndexes = append(validIndexes, i)
+	}
+
+	summary := BulkSummary{
+		Total:   len(items),
+		Invalid: len(items) - len(valid),
+	}
+	for i, ok := range apply(valid) {
+		result := &results[validIndexes[i]]
+		if ok {
+			result.Status = okStatus
+			summary.Succeeded++
+		} else {
+			result.Status = failedStatus
+			summary.Skipped++
+		}
+	}
+
+	controller.WriteSuccess(gin.H{
+		"summary": summary,
+		"results": results,
+	}, c)
+}
+
+//nolint:wrapcheck
+func validateItem(model SubscribeModel) error {
+	err := binding.Validator.ValidateStruct(model)
+	if err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) && len(validationErrs) > 0 {
+			return errors.ConvertToValidationError(validationErrs)[0]
+		}
+
+		return err
+	}
+
+	return nil
+}
+
+// errorMessage returns the user facing message of an item error.
+func errorMessage(err error) string {
+	var cErr errors.Error
+	if errors.As(err, &cErr) {
+		return cErr.Message
+	}
+
+	return err.Error()
+}
+
+// readBulkItems parses the addresses of a bulk request. JSON, NDJSON and CSV payloads are supported either as the
+// request body or as a multipart file upload.
+func readBulkItems(c *gin.Context) ([]bulkItem, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, MaxBulkBodyBytes)
+
+	var (
+		items []bulkItem
+		err   error
+	)
+	switch c.ContentType() {
+	case ndjsonContentType:
+		items, err = readNDJSONItems(c.Request.Body)
+	case csvContentType:
+		items, err = readCSVItems(c.Request.Body)
+	case binding.MIMEMultipartPOSTForm:
+		items, err = readUploadedItems(c)
+	default:
+		items, err = readJSONItems(c)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(items) == 0 {
+		return nil, ErrNoAddresses
+	}
+	if len(items) > MaxBulkAddresses {
+		return nil, ErrTooManyAddresses
+	}
+
+	return items, nil
+}
+
+func readJSONItems(c *gin.Context) ([]bulkItem, error) {
+	model, err := controller.BindBody[BulkAddressModel](c)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]bulkItem, 0, len(model.Addresses))
+	for _, address := range model.Addresses {
+		items = append(items, bulkItem{model: SubscribeModel{
+			Address: address,
+			Label:   model.Label,
+			Tags:    model.Tags,
+			OwnerID: model.OwnerID,
+		}})
+	}
+
+	return items, nil
+}
+
+func readUploadedItems(c *gin.Context) ([]bulkItem, error) {
+	fileHeader, err := c.FormFile(uploadFormField)
+	if err != nil {
+		return nil, ErrMissingUpload
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open uploaded file")
+	}
+	defer file.Close()
+
+	switch strings.ToLower(filepath.Ext(fileHeader.Filename)) {
+	case ".csv":
+		return readCSVItems(file)
+	case ".ndjson", ".jsonl":
+		return readNDJSONItems(file)
+	default:
+		return nil, ErrUnsupportedUpload
+	}
+}
+
+// readNDJSONItems parses a payload which each line of it is a `SubscribeModel` JSON object.
+func readNDJSONItems(r io.Reader) ([]bulkItem, error) {
+	items := make([]bulkItem, 0)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var model SubscribeModel
+		err := json.Unmarshal([]byte(line), &model)
+		if err != nil {
+			items = append(items, bulkItem{model: SubscribeModel{Address: line}, err: ErrMalformedLine})
+
+			continue
+		}
+
+		items = append(items, bulkItem{model: model})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, controller.ErrMalformedRequest
+	}
+
+	return items, nil
+}
+
+// readCSVItems parses a payload which the first column of each row is an address and the optional second column is its
+// label. A header row is skipped if present.
+func readCSVItems(r io.Reader) ([]bulkItem, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	items := make([]bulkItem, 0)
+	for i := 0; ; i++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, controller.ErrMalformedRequest
+		}
+
+		address := strings.TrimSpace(record[0])
+		if address == "" || (i == 0 && strings.EqualFold(address, "address")) {
+			continue
+		}
+
+		item := bulkItem{model: SubscribeModel{Address: address}}
+		if len(record) > 1 {
+			item.model.Label = strings.TrimSpace(record[1])
+		}
+
+		items = append(items, item)
+	}
+
+	return items, nil
+}
@@ -2,10 +2,19 @@ package address
 
 import (
 	"blockbook/pkg/errors"
+	"fmt"
 	"net/http"
 )
 
 var (
 	ErrAddressAlreadySubscribed = errors.New("address already subscribed", errors.WithType("addressAlreadySubscribed"), errors.WithStatusCode(http.StatusConflict))
 	ErrAddressNotSubscribed     = errors.New("address not subscribed", errors.WithType("addressNotSubscribed"), errors.WithStatusCode(http.StatusNotFound))
+	ErrNoAddresses              = errors.New("no addresses provided", errors.WithType("noAddresses"), errors.WithStatusCode(http.StatusBadRequest))
+	ErrTooManyAddresses         = errors.New(fmt.Sprintf("at most %d addresses can be provided in a single request", MaxBulkAddresses), errors.WithType("tooManyAddresses"), errors.WithStatusCode(http.StatusRequestEntityTooLarge))
+	ErrMissingUpload            = errors.New("`file` field is required", errors.WithType("missingUpload"), errors.WithStatusCode(http.StatusBadRequest))
+	ErrUnsupportedUpload        = errors.New("uploaded file should be a .csv, .ndjson or .jsonl file", errors.WithType("unsupportedUpload"), errors.WithStatusCode(http.StatusBadRequest))
+	ErrMalformedLine            = errors.New("could not unmarshal line", errors.WithType("malformedLine"), errors.WithStatusCode(http.StatusBadRequest))
+	ErrInvalidBlockRange        = errors.New("fromBlock should not be greater than toBlock", errors.WithType("invalidBlockRange"), errors.WithStatusCode(http.StatusBadRequest))
+	ErrInvalidTimeRange         = errors.New("from should not be after to", errors.WithType("invalidTimeRange"), errors.WithStatusCode(http.StatusBadRequest))
+	ErrBalanceNotAvailable      = errors.New("balance is not available yet, try again later", errors.WithType("balanceNotAvailable"), errors.WithStatusCode(http.StatusNotFound))
 )
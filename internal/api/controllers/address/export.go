@@ -0,0 +1,102 @@
+package address
+
+import (
+	"blockbook/internal/export"
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/set"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// exportFlushRows is the number of rows after which the exported rows are flushed to the client.
+	exportFlushRows = 1000
+)
+
+// export streams the stored histories of the address and the other addresses of the query. All of them must be
+// subscribed by the tenant.
+func (a *Address) export(c *gin.Context) {
+	uriModel, err := controller.BindUri[AddressModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	model, err := controller.BindQuery[ExportQueryModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	if model.FromBlock != nil && model.ToBlock != nil && *model.FromBlock > *model.ToBlock {
+		controller.WriteError(ErrInvalidBlockRange, c)
+
+		return
+	}
+
+	addresses := make([]string, 0, len(model.Addresses)+1)
+	seen := set.New[string]()
+	for _, address := range append([]string{uriModel.Address}, model.Addresses...) {
+		if seen.Add(address) {
+			addresses = append(addresses, address)
+		}
+	}
+
+	histories := make([][]*bcclient.Transaction, 0, len(addresses))
+	for _, address := range addresses {
+		txs := a.parser.Transactions(controller.GetTenant(c), address)
+		if txs == nil {
+			controller.WriteError(ErrAddressNotSubscribed, c)
+
+			return
+		}
+
+		histories = append(histories, txs)
+	}
+
+	c.Header("Content-Type", export.ContentTypes[model.Format])
+	c.Header("Content-Disposition", `attachment; filename="transactions.`+model.Format+`"`)
+	c.Status(http.StatusOK)
+
+	if err := writeExport(c.Writer, addresses, histories, model); err != nil {
+		// the response has already started, So the client only notices an incomplete export.
+		controller.GetLogger(c).Warn("could not write export", zap.Error(err))
+	}
+}
+
+// writeExport writes the transactions of the histories which are inside the block range of the query, And flushes
+// them to the client every exportFlushRows rows.
+func writeExport(w gin.ResponseWriter, addresses []string, histories [][]*bcclient.Transaction, model ExportQueryModel) error {
+	writer, err := export.NewWriter(model.Format, w)
+	if err != nil {
+		return err
+	}
+
+	rows := 0
+	for i, address := range addresses {
+		for _, tx := range histories[i] {
+			if (model.FromBlock != nil && tx.BlockNumber < *model.FromBlock) || (model.ToBlock != nil && tx.BlockNumber > *model.ToBlock) {
+				continue
+			}
+
+			if err := writer.Write(export.NewRow(address, tx)); err != nil {
+				return err
+			}
+
+			rows++
+			if rows%exportFlushRows == 0 {
+				if err := writer.Flush(); err != nil {
+					return err
+				}
+				w.Flush()
+			}
+		}
+	}
+
+	return writer.Close()
+}
@@ -1,5 +1,118 @@
 package address
 
+import (
+	"blockbook/internal/api/views"
+	"blockbook/pkg/bcparser"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// checksumAddress returns the EIP-55 checksummed form of a valid address. The RPC client returns checksummed addresses,
+// So addresses sent in another case would never match any transaction.
+func checksumAddress(address string) string {
+	return common.HexToAddress(address).Hex()
+}
+
 type AddressModel struct {
 	Address string `json:"address" uri:"address" binding:"required,eth_addr"`
 }
+
+func (m *AddressModel) Normalize() {
+	m.Address = checksumAddress(m.Address)
+}
+
+type SubscribeModel struct {
+	Address string   `json:"address" binding:"required,eth_addr"`
+	Label   string   `json:"label" binding:"max=256"`
+	Tags    []string `json:"tags" binding:"max=32,dive,max=64"`
+	OwnerID string   `json:"ownerId" binding:"max=128"`
+}
+
+func (m *SubscribeModel) Normalize() {
+	m.Address = checksumAddress(m.Address)
+}
+
+func (m SubscribeModel) toSubscription() bcparser.Subscription {
+	return bcparser.Subscription{
+		Address: m.Address,
+		Label:   m.Label,
+		Tags:    m.Tags,
+		OwnerID: m.OwnerID,
+	}
+}
+
+type UpdateSubscriptionModel struct {
+	Label   *string   `json:"label" binding:"omitempty,max=256"`
+	Tags    *[]string `json:"tags" binding:"omitempty,max=32,dive,max=64"`
+	OwnerID *string   `json:"ownerId" binding:"omitempty,max=128"`
+}
+
+type SubscriptionsQueryModel struct {
+	Tag    string `form:"tag"`
+	Prefix string `form:"prefix" binding:"omitempty,max=42"`
+	After  string `form:"after"`
+	Limit  int    `form:"limit,default=100" binding:"min=1,max=1000"`
+}
+
+func (m SubscriptionsQueryModel) toQuery() bcparser.SubscriptionsQuery {
+	return bcparser.SubscriptionsQuery{
+		Tag:    m.Tag,
+		Prefix: m.Prefix,
+		After:  m.After,
+		Limit:  m.Limit,
+	}
+}
+
+// BulkAddressModel is the JSON payload of bulk requests. Label, Tags and OwnerID are applied to all subscriptions.
+type BulkAddressModel struct {
+	Addresses []string `json:"addresses" binding:"required"`
+	Label     string   `json:"label"`
+	Tags      []string `json:"tags"`
+	OwnerID   string   `json:"ownerId"`
+}
+
+type BulkItemResult struct {
+	Address string `json:"address"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+type BulkSummary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Skipped   int `json:"skipped"`
+	Invalid   int `json:"invalid"`
+}
+
+// ExportQueryModel contains the query parameters of an export. Addresses are exported alongside the address of the
+// path, And the history is limited to the blocks from FromBlock to ToBlock if they are set.
+type ExportQueryModel struct {
+	Format    string   `form:"format,default=csv" binding:"oneof=csv jsonl parquet"`
+	Addresses []string `form:"addresses" binding:"max=1000,dive,eth_addr"`
+	FromBlock *uint64  `form:"fromBlock"`
+	ToBlock   *uint64  `form:"toBlock"`
+}
+
+func (m *ExportQueryModel) Normalize() {
+	for i, address := range m.Addresses {
+		m.Addresses[i] = checksumAddress(address)
+	}
+}
+
+// TransactionsQueryModel contains the query parameters of the transactions of an address. Only the transactions of the
+// blocks mined from From to To, Inclusive, are returned if they are set.
+type TransactionsQueryModel struct {
+	views.UnitQueryModel
+	From *time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To   *time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+// contains checks whether a transaction is inside the time range of the query.
+func (m TransactionsQueryModel) contains(createdAt time.Time) bool {
+	if m.From != nil && createdAt.Before(*m.From) {
+		return false
+	}
+
+	return m.To == nil || !createdAt.After(*m.To)
+}
@@ -0,0 +1,111 @@
+package admin
+
+import (
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Admin exposes the runtime controls of the indexer to operators.
+type Admin struct {
+	indexer bcparser.Indexer
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ controller.Controller = (*Admin)(nil)
+
+func (a *Admin) PathPrefix() string {
+	return "/indexer"
+}
+
+func (a *Admin) RegisterHandlers(engine *gin.RouterGroup) {
+	engine.GET("", a.info)
+	engine.POST("/pause", a.pause)
+	engine.POST("/resume", a.resume)
+	engine.PUT("/interval", a.setInterval)
+	engine.POST("/reindex", a.reindex)
+}
+
+func (a *Admin) info(c *gin.Context) {
+	a.writeInfo(c)
+}
+
+func (a *Admin) pause(c *gin.Context) {
+	a.indexer.Pause()
+	a.writeInfo(c)
+}
+
+func (a *Admin) resume(c *gin.Context) {
+	a.indexer.Resume()
+	a.writeInfo(c)
+}
+
+func (a *Admin) setInterval(c *gin.Context) {
+	model, err := controller.BindBody[IndexIntervalModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	interval, err := time.ParseDuration(model.Interval)
+	if err != nil {
+		controller.WriteError(ErrInvalidIndexInterval, c)
+
+		return
+	}
+
+	err = a.indexer.SetIndexInterval(interval)
+	if err != nil {
+		if errors.Is(err, bcparser.ErrInvalidIndexInterval) {
+			controller.WriteError(ErrInvalidIndexInterval, c)
+		} else {
+			controller.WriteError(err, c)
+		}
+
+		return
+	}
+
+	a.writeInfo(c)
+}
+
+func (a *Admin) reindex(c *gin.Context) {
+	model, err := controller.BindBody[ReindexModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	err = a.indexer.Reindex(model.From, model.To)
+	if err != nil {
+		switch {
+		case errors.Is(err, bcparser.ErrInvalidBlockRange):
+			controller.WriteError(ErrInvalidBlockRange, c)
+		case errors.Is(err, bcparser.ErrReindexInProgress):
+			controller.WriteError(ErrReindexInProgress, c)
+		default:
+			controller.WriteError(err, c)
+		}
+
+		return
+	}
+
+	a.writeInfo(c)
+}
+
+func (a *Admin) writeInfo(c *gin.Context) {
+	controller.WriteSuccess(gin.H{
+		"indexer": newIndexerInfoModel(a.indexer.IndexerInfo()),
+	}, c)
+}
+
+func New(indexer bcparser.Indexer) *Admin {
+	return &Admin{
+		indexer: indexer,
+	}
+}
@@ -0,0 +1,12 @@
+package admin
+
+import (
+	"blockbook/pkg/errors"
+	"net/http"
+)
+
+var (
+	ErrInvalidIndexInterval = errors.New("interval must be a positive duration, e.g. 5s", errors.WithType("invalidIndexInterval"), errors.WithStatusCode(http.StatusBadRequest))
+	ErrInvalidBlockRange    = errors.New("block range must be already indexed and not larger than the re-index limit", errors.WithType("invalidBlockRange"), errors.WithStatusCode(http.StatusBadRequest))
+	ErrReindexInProgress    = errors.New("another re-index is in progress", errors.WithType("reindexInProgress"), errors.WithStatusCode(http.StatusConflict))
+)
@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"blockbook/pkg/bcparser"
+)
+
+type IndexIntervalModel struct {
+	// Interval is a Go duration string, e.g. 5s.
+	Interval string `json:"interval" binding:"required"`
+}
+
+type ReindexModel struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to" binding:"gtefield=From"`
+}
+
+type IndexerInfoModel struct {
+	bcparser.Status
+	Paused        bool                    `json:"paused"`
+	IndexInterval string                  `json:"indexInterval"`
+	LastReindex   *bcparser.ReindexStatus `json:"lastReindex"`
+}
+
+func newIndexerInfoModel(info bcparser.IndexerInfo) IndexerInfoModel {
+	return IndexerInfoModel{
+		Status:        info.Status,
+		Paused:        info.Paused,
+		IndexInterval: info.IndexInterval.String(),
+		LastReindex:   info.LastReindex,
+	}
+}
@@ -1,8 +1,11 @@
 package block
 
 import (
+	"blockbook/internal/api/scopes"
+	"blockbook/internal/api/views"
 	"blockbook/pkg/bcparser"
 	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
 
 	"github.com/gin-gonic/gin"
 )
@@ -20,7 +23,11 @@ func (b *Block) PathPrefix() string {
 }
 
 func (b *Block) RegisterHandlers(engine *gin.RouterGroup) {
-	engine.GET("/current", b.current)
+	readBlocks := controller.RequireScopes(scopes.ReadBlocks)
+
+	engine.GET("/current", readBlocks, b.current)
+	engine.GET("/status", readBlocks, b.status)
+	engine.GET("/:number", readBlocks, b.block)
 }
 
 func (b *Block) current(c *gin.Context) {
@@ -29,6 +36,43 @@ func (b *Block) current(c *gin.Context) {
 	}, c)
 }
 
+func (b *Block) status(c *gin.Context) {
+	controller.WriteSuccess(gin.H{
+		"status": b.parser.Status(),
+	}, c)
+}
+
+func (b *Block) block(c *gin.Context) {
+	model, err := controller.BindUri[BlockNumberModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	amounts, err := views.BindAmounts(c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	block, err := b.parser.Block(c.Request.Context(), controller.GetTenant(c), model.Number)
+	if err != nil {
+		if errors.Is(err, bcparser.ErrBlockNotFound) {
+			controller.WriteError(ErrBlockNotFound, c)
+		} else {
+			controller.WriteError(err, c)
+		}
+
+		return
+	}
+
+	controller.WriteSuccess(gin.H{
+		"block": amounts.BlockDetail(block),
+	}, c)
+}
+
 func New(parser bcparser.Parser) *Block {
 	return &Block{
 		parser: parser,
@@ -0,0 +1,10 @@
+package block
+
+import (
+	"blockbook/pkg/errors"
+	"net/http"
+)
+
+var (
+	ErrBlockNotFound = errors.New("block not found", errors.WithType("blockNotFound"), errors.WithStatusCode(http.StatusNotFound))
+)
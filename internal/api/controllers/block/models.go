@@ -0,0 +1,5 @@
+package block
+
+type BlockNumberModel struct {
+	Number uint64 `uri:"number"`
+}
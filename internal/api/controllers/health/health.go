@@ -7,6 +7,7 @@ import (
 )
 
 type Health struct {
+	registry *controller.HealthRegistry
 }
 
 // This piece of code is to ensure that a type implements a certain interface at compile time.
@@ -23,14 +24,15 @@ func (h *Health) RegisterHandlers(engine *gin.RouterGroup) {
 }
 
 func (h *Health) ready(c *gin.Context) {
-	controller.WriteSuccess(gin.H{}, c)
+	controller.WriteHealthReport(h.registry.CheckReadiness(c.Request.Context()), c)
 }
 
 func (h *Health) live(c *gin.Context) {
-	controller.WriteSuccess(gin.H{}, c)
-	// TODO: check if we have the latest block information
+	controller.WriteHealthReport(h.registry.CheckLiveness(c.Request.Context()), c)
 }
 
-func New() *Health {
-	return &Health{}
+func New(registry *controller.HealthRegistry) *Health {
+	return &Health{
+		registry: registry,
+	}
 }
@@ -0,0 +1,10 @@
+package transaction
+
+import (
+	"blockbook/pkg/errors"
+	"net/http"
+)
+
+var (
+	ErrTransactionNotFound = errors.New("transaction not found", errors.WithType("transactionNotFound"), errors.WithStatusCode(http.StatusNotFound))
+)
@@ -0,0 +1,5 @@
+package transaction
+
+type HashModel struct {
+	Hash string `uri:"hash" binding:"required,len=66,hexadecimal"`
+}
@@ -0,0 +1,64 @@
+package transaction
+
+import (
+	"blockbook/internal/api/scopes"
+	"blockbook/internal/api/views"
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/errors"
+
+	"github.com/gin-gonic/gin"
+)
+
+type Transaction struct {
+	parser bcparser.Parser
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ controller.Controller = (*Transaction)(nil)
+
+func (t *Transaction) PathPrefix() string {
+	return "/tx"
+}
+
+func (t *Transaction) RegisterHandlers(engine *gin.RouterGroup) {
+	engine.GET("/:hash", controller.RequireScopes(scopes.ReadTransactions), t.transaction)
+}
+
+func (t *Transaction) transaction(c *gin.Context) {
+	model, err := controller.BindUri[HashModel](c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	amounts, err := views.BindAmounts(c)
+	if err != nil {
+		controller.WriteError(err, c)
+
+		return
+	}
+
+	tx, err := t.parser.Transaction(c.Request.Context(), controller.GetTenant(c), model.Hash)
+	if err != nil {
+		if errors.Is(err, bcparser.ErrTransactionNotFound) {
+			controller.WriteError(ErrTransactionNotFound, c)
+		} else {
+			controller.WriteError(err, c)
+		}
+
+		return
+	}
+
+	controller.WriteSuccess(gin.H{
+		"transaction": amounts.IndexedTransaction(tx),
+	}, c)
+}
+
+func New(parser bcparser.Parser) *Transaction {
+	return &Transaction{
+		parser: parser,
+	}
+}
@@ -1,13 +1,13 @@
+//go:build integration
+
 package api
 
 import (
-	"blockbook/pkg/bcclient"
+	"blockbook/internal/api/views"
 	ethclient "blockbook/pkg/bcclient/eth"
 	bccparser "blockbook/pkg/bcparser/bcc"
 	"blockbook/pkg/errors"
-	"bytes"
 	"context"
-	"encoding/json"
 	"github.com/cenkalti/backoff/v4"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/ethereum/go-ethereum/core/types"
@@ -20,20 +20,17 @@ import (
 	"math/big"
 	"math/rand/v2"
 	"net/http"
-	"net/http/httptest"
 	"testing"
 	"time"
 )
 
 const (
-	parserRefreshInterval = 5 * time.Second
-	ganacheRpcAddress     = "http://localhost:8545"
-
-	wallet1PublicAddress = "0x627306090abaB3A6e1400e9345bC60c78a8BEf57"
-	wallet1PrivateKey    = "c87509a1c067bbde78beb793e6fa76530b6382a4c0241e5e4a9ec0a0f44dc0d3"
+	parserRefreshInterval    = 5 * time.Second
+	balanceReconcileInterval = time.Minute
+	ganacheRpcAddress        = "http://localhost:8545"
 
-	wallet2PublicAddress = "0xf17f52151EbEF6C7334FAD080c5704D77216b732"
-	wallet2PrivateKey    = "ae6ae8e5ccbfb04590405997ee2d52d2b330726137b875053c36d94e974d162f"
+	wallet1PrivateKey = "c87509a1c067bbde78beb793e6fa76530b6382a4c0241e5e4a9ec0a0f44dc0d3"
+	wallet2PrivateKey = "ae6ae8e5ccbfb04590405997ee2d52d2b330726137b875053c36d94e974d162f"
 
 	maxTransactionsNum = 10
 
@@ -42,36 +39,23 @@ const (
 	newBlocksThreshold    = 1
 )
 
-type apiResponse[T any] struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Result  T      `json:"result"`
-}
-
-type currentBlockResponse struct {
-	LastIndexedBlock uint64 `json:"lastIndexedBlock"`
-}
-
-type subscribeRequestBody struct {
-	Address string `json:"address"`
-}
-
-type transactionsResponse struct {
-	Transactions []bcclient.Transaction `json:"transactions"`
-}
-
 type randomTx struct {
 	fromWallet1 bool
 	amount      *big.Int
 }
 
-func setupServer(logger *zap.Logger) (http.Handler, func()) {
-	bcClient, err := ethclient.New(ganacheRpcAddress)
+func setupServer(t *testing.T, logger *zap.Logger) (http.Handler, func()) {
+	useFreshMetricsRegistry(t)
+
+	bcClient, err := ethclient.New(ganacheRpcAddress, ethclient.Options{})
 	if err != nil {
 		panic(err)
 	}
 
-	parser := bccparser.New(logger, bcClient, parserRefreshInterval)
+	parser := bccparser.New(logger, bcClient, bccparser.Options{
+		IndexInterval:            parserRefreshInterval,
+		BalanceReconcileInterval: balanceReconcileInterval,
+	})
 	server, err := NewServer(logger, Options{
 		BlockchainParser: parser,
 	})
@@ -87,47 +71,6 @@ func setupServer(logger *zap.Logger) (http.Handler, func()) {
 	}
 }
 
-func parseApiResponse[T any](t *testing.T, rec *httptest.ResponseRecorder, res *apiResponse[T]) {
-	assert.Equal(t, 200, rec.Code)
-
-	err := json.Unmarshal(rec.Body.Bytes(), res)
-	assert.NoError(t, err)
-	assert.True(t, res.Success)
-}
-
-func getCurrentBlock(t *testing.T, handler http.Handler) uint64 {
-	rec := httptest.NewRecorder()
-	req, err := http.NewRequest("GET", "/public/api/v1/block/current", nil)
-	if err != nil {
-		panic(err)
-	}
-	handler.ServeHTTP(rec, req)
-
-	var res apiResponse[currentBlockResponse]
-	parseApiResponse(t, rec, &res)
-
-	return res.Result.LastIndexedBlock
-}
-
-func subscribeAddress(t *testing.T, handler http.Handler, address string) {
-	body, err := json.Marshal(subscribeRequestBody{
-		Address: address,
-	})
-	if err != nil {
-		panic(err)
-	}
-
-	rec := httptest.NewRecorder()
-	req, err := http.NewRequest("POST", "/public/api/v1/address/subscribe", bytes.NewReader(body))
-	if err != nil {
-		panic(err)
-	}
-	handler.ServeHTTP(rec, req)
-
-	var res apiResponse[struct{}]
-	parseApiResponse(t, rec, &res)
-}
-
 func generateRandomTxs() []randomTx {
 	txNum := rand.UintN(maxTransactionsNum) + 1
 	txs := make([]randomTx, 0, txNum)
@@ -142,20 +85,6 @@ func generateRandomTxs() []randomTx {
 	return txs
 }
 
-func getTransactions(t *testing.T, handler http.Handler, address string) []bcclient.Transaction {
-	rec := httptest.NewRecorder()
-	req, err := http.NewRequest("GET", "/public/api/v1/address/"+address+"/transactions", nil)
-	if err != nil {
-		panic(err)
-	}
-	handler.ServeHTTP(rec, req)
-
-	var res apiResponse[transactionsResponse]
-	parseApiResponse(t, rec, &res)
-
-	return res.Result.Transactions
-}
-
 func sendTransaction(t *testing.T, privateHexKey, publicHexKey, toHexAddress string, amount *big.Int) {
 	client, err := goeth.Dial(ganacheRpcAddress)
 	if err != nil {
@@ -222,16 +151,21 @@ func waitForNewBlocks(t *testing.T, logger *zap.Logger, handler http.Handler, la
 	}
 }
 
-func compareTxs(t *testing.T, handler http.Handler, wanted []bcclient.Transaction) bool {
-	wallet1Txs := getTransactions(t, handler, wallet1PublicAddress)
-	wallet2Txs := getTransactions(t, handler, wallet2PublicAddress)
+func compareTxs(t *testing.T, handler http.Handler, wanted []views.Transaction) bool {
+	wallet1Txs := getTransactions(t, handler, wallet1PublicAddress, "")
+	wallet2Txs := getTransactions(t, handler, wallet2PublicAddress, "")
 
+	amountOf := func(tx views.Transaction) *big.Int {
+		amount, _ := new(big.Int).SetString(tx.Amount, 10)
+
+		return amount
+	}
 	opts := []cmp.Option{
-		cmpopts.SortSlices(func(a, b bcclient.Transaction) bool {
-			return a.Amount.Cmp(b.Amount) == 1
+		cmpopts.SortSlices(func(a, b views.Transaction) bool {
+			return amountOf(a).Cmp(amountOf(b)) == 1
 		}),
-		cmp.Comparer(func(a, b bcclient.Transaction) bool {
-			return a.FromAddress == b.FromAddress && a.ToAddress == b.ToAddress && a.Amount.Cmp(b.Amount) == 0
+		cmp.Comparer(func(a, b views.Transaction) bool {
+			return a.FromAddress == b.FromAddress && a.ToAddress == b.ToAddress && a.Amount == b.Amount
 		}),
 	}
 	if !cmp.Equal(wanted, wallet1Txs, opts...) {
@@ -246,7 +180,7 @@ func compareTxs(t *testing.T, handler http.Handler, wanted []bcclient.Transactio
 
 func TestTransactions(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
-	httpHandler, teardown := setupServer(logger)
+	httpHandler, teardown := setupServer(t, logger)
 	defer teardown()
 
 	lastIndexedBlock := getCurrentBlock(t, httpHandler)
@@ -260,7 +194,7 @@ func TestTransactions(t *testing.T) {
 
 	// generate random transactions
 	txs := generateRandomTxs()
-	expectedTxs := make([]bcclient.Transaction, 0, len(txs))
+	expectedTxs := make([]views.Transaction, 0, len(txs))
 	for i, tx := range txs {
 		fromPublic := wallet1PublicAddress
 		fromPrivate := wallet1PrivateKey
@@ -275,10 +209,10 @@ func TestTransactions(t *testing.T) {
 		logger.Sugar().Infof("Sending tx #%d from %s to %s with value of %d", i, fromPublic, toPublic, tx.amount)
 		sendTransaction(t, fromPrivate, fromPublic, toPublic, tx.amount)
 
-		expectedTxs = append(expectedTxs, bcclient.Transaction{
+		expectedTxs = append(expectedTxs, views.Transaction{
 			FromAddress: fromPublic,
 			ToAddress:   toPublic,
-			Amount:      tx.amount,
+			Amount:      tx.amount.String(),
 		})
 	}
 
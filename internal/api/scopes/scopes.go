@@ -0,0 +1,25 @@
+package scopes
+
+import (
+	"blockbook/pkg/controller"
+)
+
+// Scopes which can be granted to API keys.
+const (
+	ReadBlocks         = "read:blocks"
+	ReadTransactions   = "read:transactions"
+	ReadSubscriptions  = "read:subscriptions"
+	WriteSubscriptions = "write:subscriptions"
+	// Admin grants access to the admin api, which is only served when authentication is enabled.
+	Admin = "admin"
+)
+
+// All contains every scope which can be granted to API keys, Including the wildcard scope.
+var All = []string{
+	ReadBlocks,
+	ReadTransactions,
+	ReadSubscriptions,
+	WriteSubscriptions,
+	Admin,
+	controller.WildcardScope,
+}
@@ -2,30 +2,69 @@ package api
 
 import (
 	"blockbook/internal/api/controllers/address"
+	"blockbook/internal/api/controllers/admin"
 	"blockbook/internal/api/controllers/block"
 	"blockbook/internal/api/controllers/health"
+	"blockbook/internal/api/controllers/transaction"
+	"blockbook/internal/api/scopes"
 	"blockbook/pkg/bcparser"
 	"blockbook/pkg/controller"
 	"net/http"
 
+	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
 )
 
 type Options struct {
 	Controller       controller.Options
 	BlockchainParser bcparser.Parser
+	// Indexer is controlled by the admin api. The admin api is served only if it's set and APIKeyStore is not nil.
+	Indexer bcparser.Indexer
+	// APIKeyStore is used to authenticate the public and admin apis. Authentication is disabled if it's nil.
+	APIKeyStore controller.APIKeyStore
+	// PublicRateLimits limits the requests of the public api. Limits are disabled if they are zero.
+	PublicRateLimits controller.GroupRateLimits
+	// AdminRateLimits limits the requests of the admin api. Limits are disabled if they are zero.
+	AdminRateLimits controller.GroupRateLimits
+	// HealthRegistry contains the readiness and liveness checks served by the health endpoints. If it's nil, The
+	// service is always reported as healthy.
+	HealthRegistry *controller.HealthRegistry
 }
 
 func NewServer(logger *zap.Logger, options Options) (*http.Server, error) {
 	apiV1Group := controller.NewGroup("/api/v1",
 		block.New(options.BlockchainParser),
 		address.New(options.BlockchainParser),
+		transaction.New(options.BlockchainParser),
 	)
-	publicGroup := controller.NewGroup("/public", apiV1Group)
+	authMiddleware := controller.Anonymous()
+	if options.APIKeyStore != nil {
+		authMiddleware = controller.Authenticate(options.APIKeyStore)
+	}
+	publicGroup := controller.NewGroupWithMiddlewares("/public", []gin.HandlerFunc{
+		controller.RateLimitByIP("public", controller.NewRateLimiter(options.PublicRateLimits.PerIP)),
+		authMiddleware,
+		controller.RateLimitByKey("public", controller.NewRateLimiter(options.PublicRateLimits.PerKey)),
+	}, apiV1Group)
+
+	healthRegistry := options.HealthRegistry
+	if healthRegistry == nil {
+		healthRegistry = controller.NewHealthRegistry()
+	}
 
 	apiControllers := map[string]controller.Controller{
 		"public": publicGroup,
-		"health": health.New(),
+		"health": health.New(healthRegistry),
+	}
+
+	// the admin api is never exposed without authentication.
+	if options.Indexer != nil && options.APIKeyStore != nil {
+		apiControllers["admin"] = controller.NewGroupWithMiddlewares("/admin", []gin.HandlerFunc{
+			controller.RateLimitByIP("admin", controller.NewRateLimiter(options.AdminRateLimits.PerIP)),
+			controller.Authenticate(options.APIKeyStore),
+			controller.RateLimitByKey("admin", controller.NewRateLimiter(options.AdminRateLimits.PerKey)),
+			controller.RequireScopes(scopes.Admin),
+		}, admin.New(options.Indexer))
 	}
 
 	return controller.NewServer(logger, apiControllers, options.Controller)
@@ -0,0 +1,187 @@
+// Package views contains the JSON representations of the parser types which contain amounts. Amounts are written as
+// decimal strings of wei, So JavaScript clients do not lose precision, Alongside the same
+// amount formatted in the unit requested by the client.
+package views
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/controller"
+	"blockbook/pkg/units"
+	"math/big"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// UnitQueryModel contains the unit query parameter of the endpoints which return amounts.
+type UnitQueryModel struct {
+	Unit string `form:"unit,default=ether" binding:"oneof=wei gwei ether"`
+}
+
+// Amounts formats amounts of wei in a unit.
+type Amounts struct {
+	unit     string
+	decimals int
+}
+
+func NewAmounts(unit string) (Amounts, error) {
+	decimals, err := units.UnitDecimals(unit)
+	if err != nil {
+		return Amounts{}, err
+	}
+
+	return Amounts{
+		unit:     unit,
+		decimals: decimals,
+	}, nil
+}
+
+// BindAmounts creates the Amounts in the unit given by the query parameters of the request.
+func BindAmounts(c *gin.Context) (Amounts, error) {
+	model, err := controller.BindQuery[UnitQueryModel](c)
+	if err != nil {
+		return Amounts{}, err
+	}
+
+	return NewAmounts(model.Unit)
+}
+
+func (a Amounts) format(amount *big.Int) string {
+	return units.Format(amount, a.decimals)
+}
+
+type Transaction struct {
+	Hash        string `json:"hash"`
+	FromAddress string `json:"fromAddress"`
+	ToAddress   string `json:"toAddress"`
+	// Amount is in wei, FormattedAmount is the same amount in Unit.
+	Amount          string    `json:"amount"`
+	FormattedAmount string    `json:"formattedAmount"`
+	Unit            string    `json:"unit"`
+	BlockNumber     uint64    `json:"blockNumber"`
+	Position        uint      `json:"position"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+func (a Amounts) Transaction(tx *bcclient.Transaction) Transaction {
+	return Transaction{
+		Hash:            tx.Hash,
+		FromAddress:     tx.FromAddress,
+		ToAddress:       tx.ToAddress,
+		Amount:          units.Format(tx.Amount, 0),
+		FormattedAmount: a.format(tx.Amount),
+		Unit:            a.unit,
+		BlockNumber:     tx.BlockNumber,
+		Position:        tx.Position,
+		CreatedAt:       tx.CreatedAt,
+	}
+}
+
+func (a Amounts) Transactions(txs []*bcclient.Transaction) []Transaction {
+	result := make([]Transaction, 0, len(txs))
+	for _, tx := range txs {
+		result = append(result, a.Transaction(tx))
+	}
+
+	return result
+}
+
+type IndexedTransaction struct {
+	Transaction
+	WatchedAddresses []string                `json:"watchedAddresses"`
+	Subscriptions    []bcparser.Subscription `json:"subscriptions"`
+	Indexed          bool                    `json:"indexed"`
+}
+
+func (a Amounts) IndexedTransaction(tx bcparser.IndexedTransaction) IndexedTransaction {
+	return IndexedTransaction{
+		Transaction:      a.Transaction(&tx.Transaction),
+		WatchedAddresses: tx.WatchedAddresses,
+		Subscriptions:    tx.Subscriptions,
+		Indexed:          tx.Indexed,
+	}
+}
+
+type BlockDetail struct {
+	Number              uint64        `json:"number"`
+	Hash                string        `json:"hash"`
+	ParentHash          string        `json:"parentHash"`
+	Timestamp           time.Time     `json:"timestamp"`
+	TransactionCount    int           `json:"transactionCount"`
+	WatchedTransactions []Transaction `json:"watchedTransactions"`
+	Indexed             bool          `json:"indexed"`
+}
+
+func (a Amounts) BlockDetail(block bcparser.BlockDetail) BlockDetail {
+	return BlockDetail{
+		Number:              block.Number,
+		Hash:                block.Hash,
+		ParentHash:          block.ParentHash,
+		Timestamp:           block.Timestamp,
+		TransactionCount:    block.TransactionCount,
+		WatchedTransactions: a.Transactions(block.WatchedTransactions),
+		Indexed:             block.Indexed,
+	}
+}
+
+type Balance struct {
+	Amount          string `json:"balance"`
+	FormattedAmount string `json:"formattedBalance"`
+	Unit            string `json:"unit"`
+	BlockNumber     uint64 `json:"blockNumber"`
+}
+
+func (a Amounts) Balance(balance bcparser.Balance) Balance {
+	return Balance{
+		Amount:          units.Format(balance.Amount, 0),
+		FormattedAmount: a.format(balance.Amount),
+		Unit:            a.unit,
+		BlockNumber:     balance.BlockNumber,
+	}
+}
+
+type Counterparty struct {
+	Address         string `json:"address"`
+	TxCount         uint64 `json:"txCount"`
+	Volume          string `json:"volume"`
+	FormattedVolume string `json:"formattedVolume"`
+}
+
+type Stats struct {
+	TotalReceived          string         `json:"totalReceived"`
+	FormattedTotalReceived string         `json:"formattedTotalReceived"`
+	TotalSent              string         `json:"totalSent"`
+	FormattedTotalSent     string         `json:"formattedTotalSent"`
+	Unit                   string         `json:"unit"`
+	TxCountIn              uint64         `json:"txCountIn"`
+	TxCountOut             uint64         `json:"txCountOut"`
+	FirstSeenBlock         uint64         `json:"firstSeenBlock,omitempty"`
+	LastSeenBlock          uint64         `json:"lastSeenBlock,omitempty"`
+	TopCounterparties      []Counterparty `json:"topCounterparties"`
+}
+
+func (a Amounts) Stats(stats bcparser.Stats) Stats {
+	counterparties := make([]Counterparty, 0, len(stats.TopCounterparties))
+	for _, counterparty := range stats.TopCounterparties {
+		counterparties = append(counterparties, Counterparty{
+			Address:         counterparty.Address,
+			TxCount:         counterparty.TxCount,
+			Volume:          units.Format(counterparty.Volume, 0),
+			FormattedVolume: a.format(counterparty.Volume),
+		})
+	}
+
+	return Stats{
+		TotalReceived:          units.Format(stats.TotalReceived, 0),
+		FormattedTotalReceived: a.format(stats.TotalReceived),
+		TotalSent:              units.Format(stats.TotalSent, 0),
+		FormattedTotalSent:     a.format(stats.TotalSent),
+		Unit:                   a.unit,
+		TxCountIn:              stats.TxCountIn,
+		TxCountOut:             stats.TxCountOut,
+		FirstSeenBlock:         stats.FirstSeenBlock,
+		LastSeenBlock:          stats.LastSeenBlock,
+		TopCounterparties:      counterparties,
+	}
+}
@@ -22,17 +22,91 @@ type Config struct {
 			MetricsPath        string        `env:"API_SERVER_METRICS_PATH" env-default:"/metrics" yaml:"metricsPath"`
 			MetricsSubSystem   string        `env:"API_SERVER_METRICS_SUBSYSTEM" env-default:"api" yaml:"metricsSubSystem"`
 			DefaultHandlerName string        `env:"API_SERVER_DEFAULT_HANDLER_NAME" env-default:"api-unknown" yaml:"defaultHandlerName"`
+			// TrustedProxies are the IPs and CIDRs of the reverse proxies which the client IP is read from their
+			// X-Forwarded-For header. No proxy is trusted by default, So the client IP is the remote address.
+			TrustedProxies []string `env:"API_SERVER_TRUSTED_PROXIES" yaml:"trustedProxies"`
 		} `yaml:"server"`
+		Auth struct {
+			Enabled bool     `env:"API_AUTH_ENABLED" env-default:"false" yaml:"enabled"`
+			Keys    []APIKey `yaml:"keys"`
+		} `yaml:"auth"`
+		RateLimit struct {
+			Public GroupRateLimits `env-prefix:"API_RATE_LIMIT_PUBLIC_" yaml:"public"`
+			Admin  GroupRateLimits `env-prefix:"API_RATE_LIMIT_ADMIN_" yaml:"admin"`
+		} `yaml:"rateLimit"`
 	} `yaml:"api"`
 	Parser struct {
 		Client struct {
 			RpcAddress string `env:"PARSER_CLIENT_RPC_ADDRESS" env-default:"http://127.0.0.1:8545" yaml:"rpcAddress"`
+			// BlockBatchSize and ReceiptBatchSize are the maximum number of blocks and receipts fetched in a single
+			// json-rpc batch request. The parser fetches BlockBatchSize blocks at once while catching up, re-indexing
+			// or backfilling too.
+			BlockBatchSize   int `env:"PARSER_CLIENT_BLOCK_BATCH_SIZE" env-default:"20" yaml:"blockBatchSize"`
+			ReceiptBatchSize int `env:"PARSER_CLIENT_RECEIPT_BATCH_SIZE" env-default:"100" yaml:"receiptBatchSize"`
+			// RecordCassette is the path which the RPC responses are recorded into on shutdown. Recording is disabled if
+			// it's empty.
+			RecordCassette string `env:"PARSER_CLIENT_RECORD_CASSETTE" env-default:"" yaml:"recordCassette"`
+			Cache          struct {
+				Enabled       bool   `env:"PARSER_CLIENT_CACHE_ENABLED" env-default:"true" yaml:"enabled"`
+				Size          int    `env:"PARSER_CLIENT_CACHE_SIZE" env-default:"1000" yaml:"size"`
+				FinalityDepth uint64 `env:"PARSER_CLIENT_CACHE_FINALITY_DEPTH" env-default:"64" yaml:"finalityDepth"`
+				// Dir is the directory of the on-disk cache, Finalized blocks are only cached in-memory if it's empty.
+				Dir string `env:"PARSER_CLIENT_CACHE_DIR" env-default:"" yaml:"dir"`
+			} `yaml:"cache"`
+			// RateLimit throttles the requests sent to the node, Requests rate limited by the node are always retried after
+			// their Retry-After.
+			RateLimit struct {
+				RequestsPerSecond float64 `env:"PARSER_CLIENT_RATE_LIMIT_REQUESTS_PER_SECOND" env-default:"0" yaml:"requestsPerSecond"`
+				Burst             int     `env:"PARSER_CLIENT_RATE_LIMIT_BURST" env-default:"10" yaml:"burst"`
+				MaxConcurrency    int     `env:"PARSER_CLIENT_RATE_LIMIT_MAX_CONCURRENCY" env-default:"0" yaml:"maxConcurrency"`
+				MaxRetries        int     `env:"PARSER_CLIENT_RATE_LIMIT_MAX_RETRIES" env-default:"3" yaml:"maxRetries"`
+			} `yaml:"rateLimit"`
 		} `yaml:"client"`
-		IndexInterval time.Duration `env:"PARSER_INDEX_INTERVAL" env-default:"10s" yaml:"indexInterval"`
+		IndexInterval            time.Duration `env:"PARSER_INDEX_INTERVAL" env-default:"10s" yaml:"indexInterval"`
+		BalanceReconcileInterval time.Duration `env:"PARSER_BALANCE_RECONCILE_INTERVAL" env-default:"5m" yaml:"balanceReconcileInterval"`
+		Liveness                 struct {
+			MaxLag       uint64        `env:"PARSER_LIVENESS_MAX_LAG" env-default:"100" yaml:"maxLag"`
+			MaxStaleness time.Duration `env:"PARSER_LIVENESS_MAX_STALENESS" env-default:"5m" yaml:"maxStaleness"`
+		} `yaml:"liveness"`
+		// Snapshot.Path is the file which the parser state is restored from at startup and written into periodically and on
+		// shutdown. Snapshots are disabled if it's empty.
+		Snapshot struct {
+			Path     string        `env:"PARSER_SNAPSHOT_PATH" env-default:"" yaml:"path"`
+			Interval time.Duration `env:"PARSER_SNAPSHOT_INTERVAL" env-default:"5m" yaml:"interval"`
+		} `yaml:"snapshot"`
 	} `yaml:"parser"`
+	Tracing struct {
+		Enabled     bool    `env:"TRACING_ENABLED" env-default:"false" yaml:"enabled"`
+		Endpoint    string  `env:"TRACING_OTLP_ENDPOINT" env-default:"localhost:4318" yaml:"endpoint"`
+		Insecure    bool    `env:"TRACING_OTLP_INSECURE" env-default:"true" yaml:"insecure"`
+		ServiceName string  `env:"TRACING_SERVICE_NAME" env-default:"blockbook" yaml:"serviceName"`
+		SampleRatio float64 `env:"TRACING_SAMPLE_RATIO" env-default:"1" yaml:"sampleRatio"`
+	} `yaml:"tracing"`
 	GracefulShutdownTimeout time.Duration `env:"GRACEFUL_SHUTDOWN_TIMEOUT" env-default:"30s" yaml:"gracefulShutdownTimeout"`
 }
 
+// APIKey is an API key which can be used to access the public api.
+type APIKey struct {
+	ID string `yaml:"id"`
+	// Hash is the hex encoded SHA-256 hash of the key.
+	Hash   string   `yaml:"hash"`
+	Scopes []string `yaml:"scopes"`
+	// TenantID groups keys sharing the same subscriptions. Defaults to the key ID.
+	TenantID string `yaml:"tenantId"`
+}
+
+// GroupRateLimits contains the rate limits of a route group.
+type GroupRateLimits struct {
+	PerKey RateLimit `env-prefix:"PER_KEY_" yaml:"perKey"`
+	PerIP  RateLimit `env-prefix:"PER_IP_" yaml:"perIp"`
+}
+
+// RateLimit configures a token bucket. Setting RequestsPerSecond to zero disables the limit.
+type RateLimit struct {
+	RequestsPerSecond float64 `env:"REQUESTS_PER_SECOND" env-default:"20" yaml:"requestsPerSecond"`
+	Burst             int     `env:"BURST" env-default:"40" yaml:"burst"`
+}
+
 // Load receives the path for yaml config file and returns a filled Config struct.
 func Load(configPath string) (Config, error) {
 	var cfg Config
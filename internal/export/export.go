@@ -0,0 +1,158 @@
+package export
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/errors"
+	"blockbook/pkg/units"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+const (
+	FormatCSV     = "csv"
+	FormatJSONL   = "jsonl"
+	FormatParquet = "parquet"
+
+	CSVContentType     = "text/csv"
+	JSONLContentType   = "application/x-ndjson"
+	ParquetContentType = "application/vnd.apache.parquet"
+)
+
+var ErrUnsupportedFormat = errors.New("format should be csv, jsonl or parquet")
+
+// ContentTypes maps each format to the content type of its files.
+var ContentTypes = map[string]string{
+	FormatCSV:     CSVContentType,
+	FormatJSONL:   JSONLContentType,
+	FormatParquet: ParquetContentType,
+}
+
+// Columns is the header row of csv exports.
+var Columns = []string{
+	"address", "hash", "blockNumber", "position", "fromAddress", "toAddress", "amountWei", "amountEther", "createdAt",
+}
+
+// Row is a single transaction of an exported history. Address is the exported address which the transaction belongs
+// to, So the same transaction is exported once for each of its exported addresses.
+type Row struct {
+	Address     string    `json:"address" parquet:"address"`
+	Hash        string    `json:"hash" parquet:"hash"`
+	BlockNumber uint64    `json:"blockNumber" parquet:"blockNumber"`
+	Position    uint64    `json:"position" parquet:"position"`
+	FromAddress string    `json:"fromAddress" parquet:"fromAddress"`
+	ToAddress   string    `json:"toAddress" parquet:"toAddress"`
+	AmountWei   string    `json:"amountWei" parquet:"amountWei"`
+	AmountEther string    `json:"amountEther" parquet:"amountEther"`
+	CreatedAt   time.Time `json:"createdAt" parquet:"createdAt,timestamp(millisecond)"`
+}
+
+func NewRow(address string, tx *bcclient.Transaction) Row {
+	return Row{
+		Address:     address,
+		Hash:        tx.Hash,
+		BlockNumber: tx.BlockNumber,
+		Position:    uint64(tx.Position),
+		FromAddress: tx.FromAddress,
+		ToAddress:   tx.ToAddress,
+		AmountWei:   units.Format(tx.Amount, 0),
+		AmountEther: units.Format(tx.Amount, units.EtherDecimals),
+		CreatedAt:   tx.CreatedAt.UTC(),
+	}
+}
+
+// Writer encodes the exported rows in one of the export formats.
+type Writer interface {
+	Write(row Row) error
+	// Flush writes the buffered rows to the underlying writer.
+	Flush() error
+	// Close flushes the remaining rows and writes the trailer of the format if it has one. The underlying writer is not
+	// closed.
+	Close() error
+}
+
+type csvWriter struct {
+	writer *csv.Writer
+}
+
+func (w *csvWriter) Write(row Row) error {
+	return w.writer.Write([]string{ //nolint:wrapcheck
+		row.Address,
+		row.Hash,
+		strconv.FormatUint(row.BlockNumber, 10),
+		strconv.FormatUint(row.Position, 10),
+		row.FromAddress,
+		row.ToAddress,
+		row.AmountWei,
+		row.AmountEther,
+		row.CreatedAt.Format(time.RFC3339),
+	})
+}
+
+func (w *csvWriter) Flush() error {
+	w.writer.Flush()
+
+	return w.writer.Error() //nolint:wrapcheck
+}
+
+func (w *csvWriter) Close() error {
+	return w.Flush()
+}
+
+type jsonlWriter struct {
+	encoder *json.Encoder
+}
+
+func (w *jsonlWriter) Write(row Row) error {
+	return w.encoder.Encode(row) //nolint:wrapcheck
+}
+
+func (w *jsonlWriter) Flush() error {
+	return nil
+}
+
+func (w *jsonlWriter) Close() error {
+	return nil
+}
+
+type parquetWriter struct {
+	writer *parquet.GenericWriter[Row]
+}
+
+func (w *parquetWriter) Write(row Row) error {
+	_, err := w.writer.Write([]Row{row})
+
+	return err //nolint:wrapcheck
+}
+
+// Flush ends the current row group, So its rows can be written to the underlying writer.
+func (w *parquetWriter) Flush() error {
+	return w.writer.Flush() //nolint:wrapcheck
+}
+
+func (w *parquetWriter) Close() error {
+	return w.writer.Close() //nolint:wrapcheck
+}
+
+// NewWriter creates a writer which encodes the rows in format into w.
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCSV:
+		writer := csv.NewWriter(w)
+		if err := writer.Write(Columns); err != nil {
+			return nil, errors.Wrap(err, "could not write csv header")
+		}
+
+		return &csvWriter{writer: writer}, nil
+	case FormatJSONL:
+		return &jsonlWriter{encoder: json.NewEncoder(w)}, nil
+	case FormatParquet:
+		return &parquetWriter{writer: parquet.NewGenericWriter[Row](w)}, nil
+	default:
+		return nil, ErrUnsupportedFormat
+	}
+}
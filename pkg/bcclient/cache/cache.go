@@ -0,0 +1,203 @@
+package cacheclient
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/errors"
+	"context"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common/lru"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultSize is the number of blocks kept in-memory if Options.Size is not set.
+	DefaultSize = 1000
+	// DefaultFinalityDepth is the reorg window if Options.FinalityDepth is not set. It matches the two epochs after
+	// which Ethereum blocks are finalized.
+	DefaultFinalityDepth = 64
+)
+
+type Options struct {
+	// Size is the number of finalized blocks which are kept in the in-memory LRU.
+	Size int
+	// FinalityDepth is the number of blocks behind the chain head which can still be reorganized, Blocks inside this
+	// window are never cached.
+	FinalityDepth uint64
+	// Dir is the directory of the on-disk cache, It's disabled if Dir is empty. It must not be shared by nodes of
+	// different chains.
+	Dir string
+	// Registerer is used to register the cache metrics, They are not exported if it's nil.
+	Registerer prometheus.Registerer
+}
+
+// Client is a decorator of `bcclient.Client` which caches finalized blocks in-memory and optionally on disk. The chain
+// head is learned from the responses of CurrentBlockNumber, So nothing is cached before it has been called. Other
+// calls are passed through to the underlying client.
+//
+// Blocks are looked up by their number alone, Since callers only know the number and checking the hash would need a
+// request to the node on every hit. This is safe because a block is only cached once the node has reported a head
+// FinalityDepth blocks past it, And a finalized block is never replaced on the canonical chain. It relies on
+// FinalityDepth being at least as deep as any reorg of the chain, And on the on-disk cache being used by a single
+// chain, Since blocks of another chain with the same numbers would be served as they are.
+type Client struct {
+	client        bcclient.Client
+	blocks        *lru.Cache[uint64, bcclient.Block]
+	disk          *diskCache
+	finalityDepth uint64
+	// head is the highest block number returned by CurrentBlockNumber.
+	head    atomic.Uint64
+	metrics *cacheMetrics
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ bcclient.Client = (*Client)(nil)
+
+func (c *Client) CurrentBlockNumber(ctx context.Context) (uint64, error) {
+	num, err := c.client.CurrentBlockNumber(ctx)
+	if err != nil {
+		return num, err
+	}
+
+	for {
+		head := c.head.Load()
+		if num <= head || c.head.CompareAndSwap(head, num) {
+			break
+		}
+	}
+
+	return num, nil
+}
+
+func (c *Client) Block(ctx context.Context, number uint64) (bcclient.Block, error) {
+	if block, ok := c.cached(number); ok {
+		return block, nil
+	}
+
+	c.metrics.misses.Inc()
+	block, err := c.client.Block(ctx, number)
+	if err != nil {
+		return block, err
+	}
+	c.store(block)
+
+	return block, nil
+}
+
+// Blocks serves the leading blocks of the range from the cache, And fetches the rest in a single call.
+func (c *Client) Blocks(ctx context.Context, from uint64, to uint64) ([]bcclient.Block, error) {
+	if from > to {
+		return nil, bcclient.ErrInvalidBlockRange
+	}
+
+	blocks := make([]bcclient.Block, 0, to-from+1)
+	next := from
+	for ; next <= to; next++ {
+		block, ok := c.cached(next)
+		if !ok {
+			break
+		}
+		blocks = append(blocks, block)
+	}
+
+	if next > to {
+		return blocks, nil
+	}
+
+	c.metrics.misses.Add(float64(to - next + 1))
+	fetched, err := c.client.Blocks(ctx, next, to)
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range fetched {
+		c.store(block)
+	}
+
+	return append(blocks, fetched...), nil
+}
+
+// cached looks up a block in the in-memory and on-disk caches.
+func (c *Client) cached(number uint64) (bcclient.Block, bool) {
+	if block, ok := c.blocks.Get(number); ok {
+		c.metrics.hits.WithLabelValues(layerMemory).Inc()
+
+		return block.Clone(), true
+	}
+
+	if c.disk != nil {
+		if block, ok := c.disk.get(number); ok {
+			c.metrics.hits.WithLabelValues(layerDisk).Inc()
+			c.blocks.Add(number, block)
+
+			return block.Clone(), true
+		}
+	}
+
+	return bcclient.Block{}, false
+}
+
+// store caches a block if it's outside the reorg window.
+func (c *Client) store(block bcclient.Block) {
+	if !c.finalized(block.Number) {
+		return
+	}
+
+	c.blocks.Add(block.Number, block.Clone())
+	if c.disk != nil {
+		if err := c.disk.put(block); err != nil {
+			c.metrics.diskErrors.Inc()
+		}
+	}
+}
+
+func (c *Client) Balance(ctx context.Context, address string, blockNumber uint64) (*big.Int, error) {
+	return c.client.Balance(ctx, address, blockNumber)
+}
+
+func (c *Client) Receipt(ctx context.Context, txHash string) (bcclient.Receipt, error) {
+	return c.client.Receipt(ctx, txHash)
+}
+
+func (c *Client) BlockReceipts(ctx context.Context, number uint64) ([]bcclient.Receipt, error) {
+	return c.client.BlockReceipts(ctx, number)
+}
+
+func (c *Client) Transaction(ctx context.Context, txHash string) (bcclient.Transaction, error) {
+	return c.client.Transaction(ctx, txHash)
+}
+
+// finalized reports whether a block is outside the reorg window of the latest known head.
+func (c *Client) finalized(number uint64) bool {
+	head := c.head.Load()
+
+	return head >= c.finalityDepth && number <= head-c.finalityDepth
+}
+
+// New wraps client, The existing blocks of the on-disk cache are indexed if it's enabled.
+func New(client bcclient.Client, options Options) (*Client, error) {
+	if options.Size <= 0 {
+		options.Size = DefaultSize
+	}
+	if options.FinalityDepth == 0 {
+		options.FinalityDepth = DefaultFinalityDepth
+	}
+
+	c := &Client{
+		client:        client,
+		blocks:        lru.NewCache[uint64, bcclient.Block](options.Size),
+		finalityDepth: options.FinalityDepth,
+		metrics:       newCacheMetrics(options.Registerer),
+	}
+
+	if options.Dir != "" {
+		disk, err := newDiskCache(options.Dir)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not open on-disk cache")
+		}
+		c.disk = disk
+	}
+
+	return c, nil
+}
@@ -0,0 +1,100 @@
+package cacheclient
+
+import (
+	fakeclient "blockbook/pkg/bcclient/fake"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testFinalityDepth = 5
+)
+
+func setupCache(t *testing.T, dir string) (*Client, *fakeclient.Client) {
+	t.Helper()
+
+	fake := fakeclient.New()
+	fake.AppendBlocks(10)
+
+	client, err := New(fake, Options{
+		FinalityDepth: testFinalityDepth,
+		Dir:           dir,
+	})
+	require.NoError(t, err)
+
+	_, err = client.CurrentBlockNumber(context.Background())
+	require.NoError(t, err)
+
+	return client, fake
+}
+
+func TestCachesFinalizedBlocks(t *testing.T) {
+	ctx := context.Background()
+	client, fake := setupCache(t, "")
+
+	for range 3 {
+		block, err := client.Block(ctx, 5)
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), block.Number)
+	}
+	assert.Equal(t, 1, fake.Calls(fakeclient.MethodBlock))
+	assert.Equal(t, float64(2), testutil.ToFloat64(client.metrics.hits.WithLabelValues(layerMemory)))
+	assert.Equal(t, float64(1), testutil.ToFloat64(client.metrics.misses))
+}
+
+func TestDoesNotCacheBlocksInsideReorgWindow(t *testing.T) {
+	ctx := context.Background()
+	client, fake := setupCache(t, "")
+
+	before, err := client.Block(ctx, 8)
+	require.NoError(t, err)
+
+	fake.Reorg(3)
+	fake.AppendBlocks(3)
+
+	after, err := client.Block(ctx, 8)
+	require.NoError(t, err)
+	assert.NotEqual(t, before.Hash, after.Hash)
+	assert.Equal(t, 2, fake.Calls(fakeclient.MethodBlock))
+}
+
+func TestServesBlocksFromDisk(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	client, _ := setupCache(t, dir)
+	block, err := client.Block(ctx, 3)
+	require.NoError(t, err)
+
+	// a new instance only has the on-disk cache to serve from.
+	client, fake := setupCache(t, dir)
+	cached, err := client.Block(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, block, cached)
+	assert.Equal(t, 0, fake.Calls(fakeclient.MethodBlock))
+	assert.Equal(t, float64(1), testutil.ToFloat64(client.metrics.hits.WithLabelValues(layerDisk)))
+}
+
+func TestRemovesBlockFilesOfOtherVersions(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+
+	// files written before the version has been added to the names.
+	outdated := filepath.Join(dir, "3-0xabc.json")
+	require.NoError(t, os.WriteFile(outdated, []byte(`{"Number": 3}`), 0o600))
+
+	client, fake := setupCache(t, dir)
+	assert.NoFileExists(t, outdated)
+
+	block, err := client.Block(ctx, 3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), block.Number)
+	assert.Equal(t, 1, fake.Calls(fakeclient.MethodBlock))
+	assert.FileExists(t, filepath.Join(dir, blockFileName(block.Number, block.Hash)))
+}
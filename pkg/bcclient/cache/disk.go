@@ -0,0 +1,185 @@
+package cacheclient
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/errors"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	// blockFileVersion is the version of the cached block format, It's part of the file names and must be bumped
+	// whenever the format or the meaning of a field changes. Files of other versions are removed at startup.
+	blockFileVersion = 2
+	blockFileExt     = ".json"
+	dirPerm          = 0o755
+)
+
+// diskCache keeps finalized blocks in a directory, One json file per block named after its number, hash and the
+// format version.
+type diskCache struct {
+	dir string
+
+	mu sync.RWMutex
+	// files contains the file name of each cached block by its number.
+	files map[uint64]string
+}
+
+func (d *diskCache) get(number uint64) (bcclient.Block, bool) {
+	d.mu.RLock()
+	name, ok := d.files[number]
+	d.mu.RUnlock()
+	if !ok {
+		return bcclient.Block{}, false
+	}
+
+	block, err := d.read(name)
+	if err != nil || block.Number != number {
+		// the file has been removed or corrupted, It will be replaced on the next put.
+		d.mu.Lock()
+		delete(d.files, number)
+		d.mu.Unlock()
+
+		return bcclient.Block{}, false
+	}
+
+	return block, true
+}
+
+func (d *diskCache) read(name string) (bcclient.Block, error) {
+	data, err := os.ReadFile(filepath.Join(d.dir, name))
+	if err != nil {
+		return bcclient.Block{}, errors.Wrap(err, "could not read block file")
+	}
+
+	var block bcclient.Block
+	if err := json.Unmarshal(data, &block); err != nil {
+		return bcclient.Block{}, errors.Wrap(err, "could not decode block file")
+	}
+
+	return block, nil
+}
+
+// put writes the block atomically, So readers never see a partially written file.
+func (d *diskCache) put(block bcclient.Block) error {
+	name := blockFileName(block.Number, block.Hash)
+
+	d.mu.RLock()
+	existing, ok := d.files[block.Number]
+	d.mu.RUnlock()
+	if ok && existing == name {
+		return nil
+	}
+
+	data, err := json.Marshal(block)
+	if err != nil {
+		return errors.Wrap(err, "could not encode block")
+	}
+
+	file, err := os.CreateTemp(d.dir, "block-*.tmp")
+	if err != nil {
+		return errors.Wrap(err, "could not create temp file")
+	}
+	defer os.Remove(file.Name())
+
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+
+		return errors.Wrap(err, "could not write block file")
+	}
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "could not close block file")
+	}
+	if err := os.Rename(file.Name(), filepath.Join(d.dir, name)); err != nil {
+		return errors.Wrap(err, "could not rename block file")
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if ok {
+		// a block with another hash has been cached under the same number by an older run.
+		_ = os.Remove(filepath.Join(d.dir, existing))
+	}
+	d.files[block.Number] = name
+
+	return nil
+}
+
+func blockFileName(number uint64, hash string) string {
+	return fmt.Sprintf("%d-%s.v%d%s", number, strings.ToLower(hash), blockFileVersion, blockFileExt)
+}
+
+// parseBlockFileName returns the block number and the format version of a file written by put. Files written before
+// the version has been added to the names are of version 1, Their transactions are stamped with the time they have
+// been decoded instead of the block time.
+func parseBlockFileName(name string) (uint64, int, bool) {
+	base, ok := strings.CutSuffix(name, blockFileExt)
+	if !ok {
+		return 0, 0, false
+	}
+
+	version := 1
+	if i := strings.LastIndex(base, ".v"); i >= 0 {
+		v, err := strconv.Atoi(base[i+len(".v"):])
+		if err != nil {
+			return 0, 0, false
+		}
+		version, base = v, base[:i]
+	}
+
+	rawNumber, _, ok := strings.Cut(base, "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	number, err := strconv.ParseUint(rawNumber, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return number, version, true
+}
+
+func newDiskCache(dir string) (*diskCache, error) {
+	if err := os.MkdirAll(dir, dirPerm); err != nil {
+		return nil, errors.Wrap(err, "could not create cache directory")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not read cache directory")
+	}
+
+	files := make(map[uint64]string, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		number, version, ok := parseBlockFileName(entry.Name())
+		if !ok {
+			continue
+		}
+
+		if version != blockFileVersion {
+			// blocks of other versions would be decoded wrongly, They are fetched and cached again when needed.
+			if err := os.Remove(filepath.Join(dir, entry.Name())); err != nil {
+				return nil, errors.Wrap(err, "could not remove outdated block file")
+			}
+
+			continue
+		}
+		files[number] = entry.Name()
+	}
+
+	return &diskCache{
+		dir:   dir,
+		files: files,
+	}, nil
+}
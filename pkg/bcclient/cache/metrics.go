@@ -0,0 +1,43 @@
+package cacheclient
+
+import (
+	"blockbook/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsSubsystem = "rpc_cache"
+
+	layerMemory = "memory"
+	layerDisk   = "disk"
+)
+
+type cacheMetrics struct {
+	hits       *prometheus.CounterVec
+	misses     prometheus.Counter
+	diskErrors prometheus.Counter
+}
+
+func newCacheMetrics(registerer prometheus.Registerer) *cacheMetrics {
+	return &cacheMetrics{
+		hits: metrics.Register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "hits_total",
+			Help:      "How many blocks have been served from the cache, partitioned by the cache layer.",
+		}, []string{"layer"})),
+		misses: metrics.Register(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "misses_total",
+			Help:      "How many blocks have been fetched from the node, including the blocks inside the reorg window.",
+		})),
+		diskErrors: metrics.Register(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "disk_errors_total",
+			Help:      "How many blocks could not be written to the on-disk cache.",
+		})),
+	}
+}
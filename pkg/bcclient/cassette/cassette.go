@@ -0,0 +1,108 @@
+package cassette
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/errors"
+	"encoding/json"
+	"io"
+	"math/big"
+	"os"
+	"strconv"
+)
+
+const (
+	// Version is the version of the cassette format written by Save. Load rejects cassettes of other versions.
+	Version = 1
+)
+
+var (
+	ErrNotRecorded        = errors.New("response has not been recorded")
+	ErrUnsupportedVersion = errors.New("unsupported cassette version")
+)
+
+// Cassette contains the recorded responses of a `bcclient.Client`. Only successful responses are recorded.
+type Cassette struct {
+	Version int `json:"version"`
+	// Heads contains the responses of CurrentBlockNumber in the order they have been returned, Consecutive duplicates
+	// are recorded once.
+	Heads         []uint64                        `json:"heads"`
+	Blocks        map[uint64]bcclient.Block       `json:"blocks"`
+	Balances      map[string]*big.Int             `json:"balances"`
+	Receipts      map[string]bcclient.Receipt     `json:"receipts"`
+	BlockReceipts map[uint64][]bcclient.Receipt   `json:"blockReceipts"`
+	Transactions  map[string]bcclient.Transaction `json:"transactions"`
+}
+
+// Save writes the cassette as json.
+func (c *Cassette) Save(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(c); err != nil {
+		return errors.Wrap(err, "could not encode cassette")
+	}
+
+	return nil
+}
+
+// SaveFile writes the cassette to the given path, The file is replaced if it exists.
+func (c *Cassette) SaveFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return errors.Wrap(err, "could not create cassette file")
+	}
+
+	if err := c.Save(file); err != nil {
+		_ = file.Close()
+
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return errors.Wrap(err, "could not close cassette file")
+	}
+
+	return nil
+}
+
+// balanceKey returns the key of a balance inside Balances.
+func balanceKey(address string, blockNumber uint64) string {
+	return address + "@" + strconv.FormatUint(blockNumber, 10)
+}
+
+// Load reads a cassette written by Save.
+func Load(r io.Reader) (*Cassette, error) {
+	c := New()
+	if err := json.NewDecoder(r).Decode(c); err != nil {
+		return nil, errors.Wrap(err, "could not decode cassette")
+	}
+
+	if c.Version != Version {
+		return nil, ErrUnsupportedVersion
+	}
+
+	return c, nil
+}
+
+// LoadFile reads a cassette from the given path.
+func LoadFile(path string) (*Cassette, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open cassette file")
+	}
+	defer file.Close()
+
+	return Load(file)
+}
+
+// New creates an empty cassette.
+func New() *Cassette {
+	return &Cassette{
+		Version:       Version,
+		Heads:         make([]uint64, 0),
+		Blocks:        make(map[uint64]bcclient.Block),
+		Balances:      make(map[string]*big.Int),
+		Receipts:      make(map[string]bcclient.Receipt),
+		BlockReceipts: make(map[uint64][]bcclient.Receipt),
+		Transactions:  make(map[string]bcclient.Transaction),
+	}
+}
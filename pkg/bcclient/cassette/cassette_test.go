@@ -0,0 +1,125 @@
+package cassette
+
+import (
+	"blockbook/pkg/bcclient"
+	fakeclient "blockbook/pkg/bcclient/fake"
+	"blockbook/pkg/bcparser"
+	bccparser "blockbook/pkg/bcparser/bcc"
+	"bytes"
+	"context"
+	"math/big"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	testIndexInterval = 10 * time.Millisecond
+	testWaitTimeout   = 5 * time.Second
+	testTenant        = "tenant"
+
+	address1 = "0x627306090abaB3A6e1400e9345bC60c78a8BEf57"
+	address2 = "0xf17f52151EbEF6C7334FAD080c5704D77216b732"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	ctx := context.Background()
+
+	client := fakeclient.New()
+	block := client.AppendBlock(fakeclient.NewTransaction(address1, address2, big.NewInt(1000)))
+	client.SetInitialBalance(address1, big.NewInt(5000))
+
+	recorder := NewRecorder(client)
+	head, err := recorder.CurrentBlockNumber(ctx)
+	require.NoError(t, err)
+	_, err = recorder.Block(ctx, block.Number)
+	require.NoError(t, err)
+	balance, err := recorder.Balance(ctx, address1, block.Number)
+	require.NoError(t, err)
+	_, err = recorder.Receipt(ctx, block.Transactions[0].Hash)
+	require.NoError(t, err)
+	_, err = recorder.Block(ctx, block.Number+1)
+	require.ErrorIs(t, err, bcclient.ErrBlockNotFound)
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	require.NoError(t, recorder.SaveFile(path))
+	cassette, err := LoadFile(path)
+	require.NoError(t, err)
+
+	player := NewPlayer(cassette)
+	replayedHead, err := player.CurrentBlockNumber(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, head, replayedHead)
+
+	replayedBlock, err := player.Block(ctx, block.Number)
+	require.NoError(t, err)
+	assert.Equal(t, block, replayedBlock)
+
+	replayedBalance, err := player.Balance(ctx, address1, block.Number)
+	require.NoError(t, err)
+	assert.Equal(t, 0, balance.Cmp(replayedBalance))
+
+	_, err = player.Block(ctx, block.Number+1)
+	assert.ErrorIs(t, err, ErrNotRecorded)
+	_, err = player.Transaction(ctx, block.Transactions[0].Hash)
+	assert.ErrorIs(t, err, ErrNotRecorded)
+}
+
+func TestLoadRejectsUnsupportedVersion(t *testing.T) {
+	_, err := Load(bytes.NewBufferString(`{"version": 0}`))
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+}
+
+func TestReplayedParserIndexesSameTransactions(t *testing.T) {
+	client := fakeclient.New()
+	client.AppendBlocks(3)
+
+	recorder := NewRecorder(client)
+	recorded := indexTransactions(t, recorder, func() uint64 {
+		client.AppendBlock(
+			fakeclient.NewTransaction(address1, address2, big.NewInt(1000)),
+			fakeclient.NewTransaction(address2, address1, big.NewInt(2000)),
+		)
+
+		return client.AppendBlocks(2)
+	})
+
+	var buf bytes.Buffer
+	require.NoError(t, recorder.Save(&buf))
+	cassette, err := Load(&buf)
+	require.NoError(t, err)
+
+	replayed := indexTransactions(t, NewPlayer(cassette), client.Head)
+	assert.Len(t, recorded, 2)
+	assert.Equal(t, recorded, replayed)
+}
+
+// indexTransactions runs a parser which watches address1 after its initial scan, Calls mine and waits until the
+// parser has indexed the returned head. The indexed transactions of address1 are returned.
+func indexTransactions(t *testing.T, client bcclient.Client, mine func() uint64) []*bcclient.Transaction {
+	t.Helper()
+
+	parser := bccparser.New(zap.NewNop(), client, bccparser.Options{
+		IndexInterval:            testIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+	})
+	defer parser.Stop()
+
+	select {
+	case <-parser.Ready():
+	case <-time.After(testWaitTimeout):
+		t.Fatal("parser did not become ready")
+	}
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	head := mine()
+	require.Eventually(t, func() bool {
+		return parser.CurrentBlockNumber() >= head
+	}, testWaitTimeout, testIndexInterval)
+
+	return parser.Transactions(testTenant, address1)
+}
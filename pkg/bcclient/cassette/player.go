@@ -0,0 +1,111 @@
+package cassette
+
+import (
+	"blockbook/pkg/bcclient"
+	"context"
+	"math/big"
+	"slices"
+	"sync"
+)
+
+// Player is an implementation of `bcclient.Client` which serves the responses of a cassette. Requests which have not
+// been recorded fail with ErrNotRecorded.
+type Player struct {
+	cassette *Cassette
+
+	mu sync.Mutex
+	// head is the index of the next response of CurrentBlockNumber inside the recorded heads.
+	head int
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ bcclient.Client = (*Player)(nil)
+
+// CurrentBlockNumber returns the recorded heads one by one in the same order they have been recorded, The last one is
+// returned once all of them have been played.
+func (p *Player) CurrentBlockNumber(_ context.Context) (uint64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	heads := p.cassette.Heads
+	if len(heads) == 0 {
+		return 0, ErrNotRecorded
+	}
+
+	num := heads[p.head]
+	if p.head < len(heads)-1 {
+		p.head++
+	}
+
+	return num, nil
+}
+
+func (p *Player) Block(_ context.Context, number uint64) (bcclient.Block, error) {
+	block, ok := p.cassette.Blocks[number]
+	if !ok {
+		return bcclient.Block{}, ErrNotRecorded
+	}
+
+	return block.Clone(), nil
+}
+
+func (p *Player) Blocks(_ context.Context, from uint64, to uint64) ([]bcclient.Block, error) {
+	if from > to {
+		return nil, bcclient.ErrInvalidBlockRange
+	}
+
+	blocks := make([]bcclient.Block, 0, to-from+1)
+	for number := from; number <= to; number++ {
+		block, ok := p.cassette.Blocks[number]
+		if !ok {
+			return nil, ErrNotRecorded
+		}
+		blocks = append(blocks, block.Clone())
+	}
+
+	return blocks, nil
+}
+
+func (p *Player) Balance(_ context.Context, address string, blockNumber uint64) (*big.Int, error) {
+	balance, ok := p.cassette.Balances[balanceKey(address, blockNumber)]
+	if !ok {
+		return nil, ErrNotRecorded
+	}
+
+	return new(big.Int).Set(balance), nil
+}
+
+func (p *Player) Receipt(_ context.Context, txHash string) (bcclient.Receipt, error) {
+	receipt, ok := p.cassette.Receipts[txHash]
+	if !ok {
+		return bcclient.Receipt{}, ErrNotRecorded
+	}
+
+	return receipt, nil
+}
+
+func (p *Player) BlockReceipts(_ context.Context, number uint64) ([]bcclient.Receipt, error) {
+	receipts, ok := p.cassette.BlockReceipts[number]
+	if !ok {
+		return nil, ErrNotRecorded
+	}
+
+	return slices.Clone(receipts), nil
+}
+
+func (p *Player) Transaction(_ context.Context, txHash string) (bcclient.Transaction, error) {
+	tx, ok := p.cassette.Transactions[txHash]
+	if !ok {
+		return bcclient.Transaction{}, ErrNotRecorded
+	}
+
+	return tx, nil
+}
+
+// NewPlayer creates a player which serves the responses of cassette, The cassette must not be modified afterward.
+func NewPlayer(cassette *Cassette) *Player {
+	return &Player{
+		cassette: cassette,
+	}
+}
@@ -0,0 +1,150 @@
+package cassette
+
+import (
+	"blockbook/pkg/bcclient"
+	"context"
+	"io"
+	"math/big"
+	"slices"
+	"sync"
+)
+
+// Recorder is a decorator of `bcclient.Client` which records the successful responses of the underlying client into a
+// cassette, So they can be replayed later using Player.
+type Recorder struct {
+	client bcclient.Client
+
+	mu       sync.Mutex
+	cassette *Cassette
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ bcclient.Client = (*Recorder)(nil)
+
+func (r *Recorder) CurrentBlockNumber(ctx context.Context) (uint64, error) {
+	num, err := r.client.CurrentBlockNumber(ctx)
+	if err != nil {
+		return num, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	heads := r.cassette.Heads
+	if len(heads) == 0 || heads[len(heads)-1] != num {
+		r.cassette.Heads = append(heads, num)
+	}
+
+	return num, nil
+}
+
+func (r *Recorder) Block(ctx context.Context, number uint64) (bcclient.Block, error) {
+	block, err := r.client.Block(ctx, number)
+	if err != nil {
+		return block, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Blocks[number] = block.Clone()
+
+	return block, nil
+}
+
+func (r *Recorder) Blocks(ctx context.Context, from uint64, to uint64) ([]bcclient.Block, error) {
+	blocks, err := r.client.Blocks(ctx, from, to)
+	if err != nil {
+		return blocks, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, block := range blocks {
+		r.cassette.Blocks[block.Number] = block.Clone()
+	}
+
+	return blocks, nil
+}
+
+func (r *Recorder) Balance(ctx context.Context, address string, blockNumber uint64) (*big.Int, error) {
+	balance, err := r.client.Balance(ctx, address, blockNumber)
+	if err != nil {
+		return balance, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Balances[balanceKey(address, blockNumber)] = new(big.Int).Set(balance)
+
+	return balance, nil
+}
+
+func (r *Recorder) Receipt(ctx context.Context, txHash string) (bcclient.Receipt, error) {
+	receipt, err := r.client.Receipt(ctx, txHash)
+	if err != nil {
+		return receipt, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Receipts[txHash] = receipt
+
+	return receipt, nil
+}
+
+func (r *Recorder) BlockReceipts(ctx context.Context, number uint64) ([]bcclient.Receipt, error) {
+	receipts, err := r.client.BlockReceipts(ctx, number)
+	if err != nil {
+		return receipts, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.BlockReceipts[number] = slices.Clone(receipts)
+
+	return receipts, nil
+}
+
+func (r *Recorder) Transaction(ctx context.Context, txHash string) (bcclient.Transaction, error) {
+	tx, err := r.client.Transaction(ctx, txHash)
+	if err != nil {
+		return tx, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cassette.Transactions[txHash] = tx
+
+	return tx, nil
+}
+
+// Save writes the responses recorded so far, Recording continues afterward.
+func (r *Recorder) Save(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cassette.Save(w)
+}
+
+// SaveFile writes the responses recorded so far to the given path.
+func (r *Recorder) SaveFile(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.cassette.SaveFile(path)
+}
+
+// NewRecorder wraps client and records its responses into an empty cassette.
+func NewRecorder(client bcclient.Client) *Recorder {
+	return &Recorder{
+		client:   client,
+		cassette: New(),
+	}
+}
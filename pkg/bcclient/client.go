@@ -8,21 +8,86 @@ import (
 
 // Transaction is a single transaction inside a blockchain block.
 type Transaction struct {
-	Hash        string    `json:"hash"`
-	FromAddress string    `json:"fromAddress"`
-	ToAddress   string    `json:"toAddress"`
-	Amount      *big.Int  `json:"amount"`
-	CreatedAt   time.Time `json:"createdAt"`
+	Hash        string   `json:"hash"`
+	FromAddress string   `json:"fromAddress"`
+	ToAddress   string   `json:"toAddress"`
+	Amount      *big.Int `json:"amount"`
+	BlockNumber uint64   `json:"blockNumber"`
+	// Position is the index of the transaction inside its block.
+	Position uint `json:"position"`
+	// CreatedAt is the timestamp of the block containing the transaction.
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // Block represents a block on a blockchain network.
 type Block struct {
-	Number       uint64
-	Transactions []*Transaction
+	Number     uint64
+	Hash       string
+	ParentHash string
+	Timestamp  time.Time
+	// TransactionCount is the number of all transactions inside the block. It may be more than the length of
+	// Transactions since clients skip transactions which are not value transfers.
+	TransactionCount int
+	Transactions     []*Transaction
+}
+
+// Clone returns a deep copy of the block, So callers which keep blocks can hand them out without sharing their
+// transactions or amounts.
+func (b Block) Clone() Block {
+	txs := make([]*Transaction, 0, len(b.Transactions))
+	for _, tx := range b.Transactions {
+		txCopy := *tx
+		if tx.Amount != nil {
+			txCopy.Amount = new(big.Int).Set(tx.Amount)
+		}
+		txs = append(txs, &txCopy)
+	}
+	b.Transactions = txs
+
+	return b
+}
+
+// Receipt contains the execution result of a transaction.
+type Receipt struct {
+	TxHash string
+	// Successful is false when the transaction has been reverted. Reverted transactions do not transfer any value, but
+	// the sender still pays the fee.
+	Successful        bool
+	GasUsed           uint64
+	EffectiveGasPrice *big.Int
+}
+
+// Fee returns the amount paid by the sender of the transaction for its execution.
+func (r Receipt) Fee() *big.Int {
+	if r.EffectiveGasPrice == nil {
+		return new(big.Int)
+	}
+
+	return new(big.Int).Mul(new(big.Int).SetUint64(r.GasUsed), r.EffectiveGasPrice)
 }
 
 // Client can be used to interact with different blockchains through RPC calls.
 type Client interface {
 	CurrentBlockNumber(ctx context.Context) (uint64, error)
 	Block(ctx context.Context, number uint64) (Block, error)
+	// Blocks returns the blocks from `from` to `to` inclusive, in order. Implementations may fetch them in fewer round
+	// trips than calling Block for each of them.
+	Blocks(ctx context.Context, from uint64, to uint64) ([]Block, error)
+	// Balance returns the native balance of an address at the end of the given block.
+	Balance(ctx context.Context, address string, blockNumber uint64) (*big.Int, error)
+	// Receipt returns the receipt of a mined transaction.
+	Receipt(ctx context.Context, txHash string) (Receipt, error)
+	// BlockReceipts returns the receipts of all transactions of a block, Including the ones which are not value
+	// transfers.
+	BlockReceipts(ctx context.Context, number uint64) ([]Receipt, error)
+	// Transaction returns a mined transaction by its hash.
+	Transaction(ctx context.Context, txHash string) (Transaction, error)
+}
+
+// CheckConnectivity checks whether the node is reachable by requesting its current block number. It can be used as a
+// readiness check.
+func CheckConnectivity(ctx context.Context, client Client) error {
+	_, err := client.CurrentBlockNumber(ctx)
+
+	return err
 }
@@ -0,0 +1,28 @@
+package bcclient
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBlockClone(t *testing.T) {
+	block := Block{
+		Number:           1,
+		TransactionCount: 2,
+		Transactions: []*Transaction{
+			{Hash: "0x1", Amount: big.NewInt(10)},
+			{Hash: "0x2"},
+		},
+	}
+
+	clone := block.Clone()
+	assert.Equal(t, block, clone)
+
+	clone.Transactions[0].Hash = "0x3"
+	clone.Transactions[0].Amount.SetInt64(20)
+	assert.Equal(t, "0x1", block.Transactions[0].Hash)
+	assert.Equal(t, big.NewInt(10), block.Transactions[0].Amount)
+	assert.Nil(t, clone.Transactions[1].Amount)
+}
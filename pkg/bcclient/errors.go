@@ -1,5 +1,47 @@
 package bcclient
 
-import "blockbook/pkg/errors"
+import (
+	"blockbook/pkg/errors"
+	"net/http"
+	"strconv"
+	"time"
+)
 
-var ErrBlockNotFound = errors.New("could not find block")
+var (
+	ErrBlockNotFound       = errors.New("could not find block")
+	ErrReceiptNotFound     = errors.New("could not find transaction receipt")
+	ErrTransactionNotFound = errors.New("could not find transaction")
+	ErrInvalidBlockRange   = errors.New("invalid block range")
+)
+
+// RateLimitedError is returned when the node rejects a request because of its rate limits. RetryAfter is zero if the
+// node has not specified when the request can be retried.
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	if e.RetryAfter > 0 {
+		return "rate limited by the node, retry after " + e.RetryAfter.String()
+	}
+
+	return "rate limited by the node"
+}
+
+// ParseRetryAfter parses the value of a Retry-After header which is either a number of seconds or an HTTP date. Zero
+// is returned if the value is missing or invalid.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0)
+	}
+
+	return 0
+}
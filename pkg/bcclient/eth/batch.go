@@ -0,0 +1,194 @@
+package ethclient
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/errors"
+	"context"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/rpc"
+)
+
+const (
+	// methodNotFoundCode is the json-rpc error code of calling a method which the node does not support.
+	methodNotFoundCode = -32601
+)
+
+// rpcBlock contains the fields of an eth_getBlockByNumber response which are used by the client.
+type rpcBlock struct {
+	Number       hexutil.Uint64     `json:"number"`
+	Hash         common.Hash        `json:"hash"`
+	ParentHash   common.Hash        `json:"parentHash"`
+	Timestamp    hexutil.Uint64     `json:"timestamp"`
+	Transactions types.Transactions `json:"transactions"`
+}
+
+// rpcBlockHeader contains the timestamp of an eth_getBlockByNumber response.
+type rpcBlockHeader struct {
+	Timestamp hexutil.Uint64 `json:"timestamp"`
+}
+
+// rpcBlockTxHashes contains the transaction hashes of an eth_getBlockByNumber response without full transactions.
+type rpcBlockTxHashes struct {
+	Transactions []common.Hash `json:"transactions"`
+}
+
+// Blocks fetches the blocks using batch requests of at most BlockBatchSize blocks.
+func (c Client) Blocks(ctx context.Context, from uint64, to uint64) ([]bcclient.Block, error) {
+	if from > to {
+		return nil, bcclient.ErrInvalidBlockRange
+	}
+
+	blocks := make([]bcclient.Block, 0, to-from+1)
+	for start := from; ; start += uint64(c.blockBatchSize) {
+		end := min(start+uint64(c.blockBatchSize)-1, to)
+		batch, err := c.blockBatch(ctx, start, end)
+		if err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, batch...)
+
+		if end == to {
+			return blocks, nil
+		}
+	}
+}
+
+func (c Client) blockBatch(ctx context.Context, from uint64, to uint64) ([]bcclient.Block, error) {
+	results := make([]*rpcBlock, to-from+1)
+	elems := make([]rpc.BatchElem, len(results))
+	for i := range elems {
+		elems[i] = rpc.BatchElem{
+			Method: "eth_getBlockByNumber",
+			Args:   []any{hexutil.EncodeUint64(from + uint64(i)), true},
+			Result: &results[i],
+		}
+	}
+
+	if err := c.batchCall(ctx, "eth_getBlockByNumber", elems); err != nil {
+		return nil, errors.Wrap(err, "could not get blocks by number")
+	}
+
+	blocks := make([]bcclient.Block, 0, len(results))
+	for i, result := range results {
+		if elems[i].Error != nil {
+			return nil, errors.Wrap(elems[i].Error, "could not get block by number")
+		}
+		if result == nil {
+			return nil, bcclient.ErrBlockNotFound
+		}
+
+		blocks = append(blocks, convertBlock(uint64(result.Number), result.Hash, result.ParentHash, uint64(result.Timestamp), result.Transactions))
+	}
+
+	return blocks, nil
+}
+
+// BlockReceipts fetches the receipts using eth_getBlockReceipts, Or batch requests of at most ReceiptBatchSize
+// eth_getTransactionReceipt calls if the node does not support it.
+func (c Client) BlockReceipts(ctx context.Context, number uint64) ([]bcclient.Receipt, error) {
+	if !c.blockReceiptsUnsupported.Load() {
+		receipts, err := c.blockReceipts(ctx, number)
+		if !isMethodNotFound(err) {
+			return receipts, err
+		}
+
+		c.blockReceiptsUnsupported.Store(true)
+	}
+
+	return c.batchBlockReceipts(ctx, number)
+}
+
+func (c Client) blockReceipts(ctx context.Context, number uint64) ([]bcclient.Receipt, error) {
+	var receipts []*types.Receipt
+	start := time.Now()
+	err := c.rpc.CallContext(ctx, &receipts, "eth_getBlockReceipts", hexutil.EncodeUint64(number))
+	c.metrics.observe("eth_getBlockReceipts", start, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get block receipts")
+	}
+
+	// the node responds with null for unknown blocks and an empty list for empty blocks.
+	if receipts == nil {
+		return nil, bcclient.ErrBlockNotFound
+	}
+
+	return c.convertReceipts(ctx, receipts)
+}
+
+func (c Client) batchBlockReceipts(ctx context.Context, number uint64) ([]bcclient.Receipt, error) {
+	var block *rpcBlockTxHashes
+	start := time.Now()
+	err := c.rpc.CallContext(ctx, &block, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false)
+	c.metrics.observe("eth_getBlockByNumber", start, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get block by number")
+	}
+	if block == nil {
+		return nil, bcclient.ErrBlockNotFound
+	}
+
+	receipts := make([]*types.Receipt, 0, len(block.Transactions))
+	for batchStart := 0; batchStart < len(block.Transactions); batchStart += c.receiptBatchSize {
+		hashes := block.Transactions[batchStart:min(batchStart+c.receiptBatchSize, len(block.Transactions))]
+
+		results := make([]*types.Receipt, len(hashes))
+		elems := make([]rpc.BatchElem, len(hashes))
+		for i, hash := range hashes {
+			elems[i] = rpc.BatchElem{
+				Method: "eth_getTransactionReceipt",
+				Args:   []any{hash},
+				Result: &results[i],
+			}
+		}
+
+		if err := c.batchCall(ctx, "eth_getTransactionReceipt", elems); err != nil {
+			return nil, errors.Wrap(err, "could not get transaction receipts")
+		}
+
+		for i, result := range results {
+			if elems[i].Error != nil {
+				return nil, errors.Wrap(elems[i].Error, "could not get transaction receipt")
+			}
+			if result == nil {
+				return nil, bcclient.ErrReceiptNotFound
+			}
+
+			receipts = append(receipts, result)
+		}
+	}
+
+	return c.convertReceipts(ctx, receipts)
+}
+
+func (c Client) convertReceipts(ctx context.Context, receipts []*types.Receipt) ([]bcclient.Receipt, error) {
+	converted := make([]bcclient.Receipt, 0, len(receipts))
+	for _, receipt := range receipts {
+		r, err := c.convertReceipt(ctx, receipt)
+		if err != nil {
+			return nil, err
+		}
+
+		converted = append(converted, r)
+	}
+
+	return converted, nil
+}
+
+// batchCall sends elems in a single batch request, Its latency is recorded under the batch label of method.
+func (c Client) batchCall(ctx context.Context, method string, elems []rpc.BatchElem) error {
+	start := time.Now()
+	err := c.rpc.BatchCallContext(ctx, elems)
+	c.metrics.observe(method+"_batch", start, err)
+
+	return err //nolint:wrapcheck
+}
+
+func isMethodNotFound(err error) bool {
+	var rpcErr rpc.Error
+
+	return errors.As(err, &rpcErr) && rpcErr.ErrorCode() == methodNotFoundCode
+}
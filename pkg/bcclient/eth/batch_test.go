@@ -0,0 +1,343 @@
+package ethclient
+
+import (
+	"blockbook/pkg/bcclient"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// testNode is a json-rpc node serving a chain of blocks with a few value transfers in each, It records the method
+// calls of every http request it receives.
+type testNode struct {
+	blocks   map[uint64]map[string]any
+	txs      map[uint64]types.Transactions
+	receipts map[common.Hash]*types.Receipt
+	// blockReceipts reports whether eth_getBlockReceipts is supported.
+	blockReceipts bool
+
+	mu       sync.Mutex
+	requests [][]string
+}
+
+func newTestNode(t *testing.T, blocks uint64, txsPerBlock int, blockReceipts bool) (*testNode, string) {
+	t.Helper()
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	signer := types.LatestSignerForChainID(big.NewInt(1))
+	to := common.HexToAddress("0xf17f52151EbEF6C7334FAD080c5704D77216b732")
+
+	node := &testNode{
+		blocks:        make(map[uint64]map[string]any),
+		txs:           make(map[uint64]types.Transactions),
+		receipts:      make(map[common.Hash]*types.Receipt),
+		blockReceipts: blockReceipts,
+	}
+
+	var nonce uint64
+	parentHash := common.Hash{}
+	for number := uint64(1); number <= blocks; number++ {
+		hash := common.BigToHash(new(big.Int).SetUint64(number))
+		for i := 0; i < txsPerBlock; i++ {
+			tx := types.MustSignNewTx(key, signer, &types.DynamicFeeTx{
+				ChainID:   big.NewInt(1),
+				Nonce:     nonce,
+				GasTipCap: big.NewInt(1),
+				GasFeeCap: big.NewInt(10),
+				Gas:       21000,
+				To:        &to,
+				Value:     big.NewInt(int64(number)),
+			})
+			nonce++
+
+			node.txs[number] = append(node.txs[number], tx)
+			node.receipts[tx.Hash()] = &types.Receipt{
+				Type:              types.DynamicFeeTxType,
+				Status:            types.ReceiptStatusSuccessful,
+				Logs:              []*types.Log{},
+				TxHash:            tx.Hash(),
+				GasUsed:           21000,
+				EffectiveGasPrice: big.NewInt(5),
+				BlockHash:         hash,
+				BlockNumber:       new(big.Int).SetUint64(number),
+				TransactionIndex:  uint(i),
+			}
+		}
+
+		node.blocks[number] = map[string]any{
+			"number":     hexutil.Uint64(number),
+			"hash":       hash,
+			"parentHash": parentHash,
+			"timestamp":  hexutil.Uint64(1700000000 + number*12),
+		}
+		parentHash = hash
+	}
+
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+
+	return node, server.URL
+}
+
+func (n *testNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	batch := len(body) > 0 && body[0] == '['
+	var reqs []rpcRequest
+	if batch {
+		if err := json.Unmarshal(body, &reqs); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+	} else {
+		var req rpcRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+		reqs = []rpcRequest{req}
+	}
+
+	methods := make([]string, 0, len(reqs))
+	resps := make([]rpcResponse, 0, len(reqs))
+	for _, req := range reqs {
+		methods = append(methods, req.Method)
+		resp := n.handle(req)
+		resp.JSONRPC = "2.0"
+		resp.ID = req.ID
+		resps = append(resps, resp)
+	}
+
+	n.mu.Lock()
+	n.requests = append(n.requests, methods)
+	n.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	if batch {
+		_ = json.NewEncoder(w).Encode(resps)
+	} else {
+		_ = json.NewEncoder(w).Encode(resps[0])
+	}
+}
+
+func (n *testNode) handle(req rpcRequest) rpcResponse {
+	switch req.Method {
+	case "eth_getBlockByNumber":
+		var number hexutil.Uint64
+		var fullTxs bool
+		_ = json.Unmarshal(req.Params[0], &number)
+		_ = json.Unmarshal(req.Params[1], &fullTxs)
+
+		block, ok := n.blocks[uint64(number)]
+		if !ok {
+			return rpcResponse{}
+		}
+
+		result := make(map[string]any, len(block)+1)
+		for k, v := range block {
+			result[k] = v
+		}
+		if fullTxs {
+			result["transactions"] = n.txs[uint64(number)]
+		} else {
+			hashes := make([]common.Hash, 0, len(n.txs[uint64(number)]))
+			for _, tx := range n.txs[uint64(number)] {
+				hashes = append(hashes, tx.Hash())
+			}
+			result["transactions"] = hashes
+		}
+
+		return rpcResponse{Result: result}
+	case "eth_getBlockReceipts":
+		if !n.blockReceipts {
+			return rpcResponse{Error: &rpcError{Code: methodNotFoundCode, Message: "method not found"}}
+		}
+
+		var number hexutil.Uint64
+		_ = json.Unmarshal(req.Params[0], &number)
+		if _, ok := n.blocks[uint64(number)]; !ok {
+			return rpcResponse{}
+		}
+
+		receipts := make([]*types.Receipt, 0, len(n.txs[uint64(number)]))
+		for _, tx := range n.txs[uint64(number)] {
+			receipts = append(receipts, n.receipts[tx.Hash()])
+		}
+
+		return rpcResponse{Result: receipts}
+	case "eth_getTransactionByHash":
+		var hash common.Hash
+		_ = json.Unmarshal(req.Params[0], &hash)
+		receipt, ok := n.receipts[hash]
+		if !ok {
+			return rpcResponse{}
+		}
+
+		tx := n.txs[receipt.BlockNumber.Uint64()][receipt.TransactionIndex]
+		encoded, _ := tx.MarshalJSON()
+		result := make(map[string]any)
+		_ = json.Unmarshal(encoded, &result)
+		result["blockNumber"] = hexutil.EncodeBig(receipt.BlockNumber)
+		result["blockHash"] = receipt.BlockHash
+
+		return rpcResponse{Result: result}
+	case "eth_getTransactionReceipt":
+		var hash common.Hash
+		_ = json.Unmarshal(req.Params[0], &hash)
+		receipt, ok := n.receipts[hash]
+		if !ok {
+			return rpcResponse{}
+		}
+
+		return rpcResponse{Result: receipt}
+	default:
+		return rpcResponse{Error: &rpcError{Code: methodNotFoundCode, Message: "method not found"}}
+	}
+}
+
+// calls returns the methods called by every http request received so far.
+func (n *testNode) calls() [][]string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.requests
+}
+
+func TestBlocksUsesBatchRequests(t *testing.T) {
+	node, address := newTestNode(t, 5, 2, true)
+	client, err := New(address, Options{BlockBatchSize: 2})
+	require.NoError(t, err)
+
+	blocks, err := client.Blocks(context.Background(), 1, 5)
+	require.NoError(t, err)
+	require.Len(t, blocks, 5)
+	for i, block := range blocks {
+		number := uint64(i + 1)
+		assert.Equal(t, number, block.Number)
+		assert.Equal(t, common.BigToHash(new(big.Int).SetUint64(number)).String(), block.Hash)
+		require.Len(t, block.Transactions, 2)
+		assert.Equal(t, node.txs[number][1].Hash().String(), block.Transactions[1].Hash)
+		assert.Equal(t, uint(1), block.Transactions[1].Position)
+		assert.Equal(t, int64(number), block.Transactions[1].Amount.Int64())
+		assert.Equal(t, block.Timestamp, block.Transactions[1].CreatedAt)
+	}
+
+	calls := node.calls()
+	require.Len(t, calls, 3)
+	assert.Len(t, calls[0], 2)
+	assert.Len(t, calls[2], 1)
+
+	_, err = client.Blocks(context.Background(), 4, 6)
+	require.ErrorIs(t, err, bcclient.ErrBlockNotFound)
+
+	_, err = client.Blocks(context.Background(), 3, 2)
+	require.ErrorIs(t, err, bcclient.ErrInvalidBlockRange)
+}
+
+func TestBlockReceipts(t *testing.T) {
+	node, address := newTestNode(t, 2, 3, true)
+	client, err := New(address, Options{})
+	require.NoError(t, err)
+
+	receipts, err := client.BlockReceipts(context.Background(), 2)
+	require.NoError(t, err)
+	require.Len(t, receipts, 3)
+	assert.Equal(t, node.txs[2][0].Hash().String(), receipts[0].TxHash)
+	assert.True(t, receipts[0].Successful)
+	assert.Equal(t, int64(5), receipts[0].EffectiveGasPrice.Int64())
+	assert.Equal(t, [][]string{{"eth_getBlockReceipts"}}, node.calls())
+
+	_, err = client.BlockReceipts(context.Background(), 3)
+	require.ErrorIs(t, err, bcclient.ErrBlockNotFound)
+}
+
+func TestBlockReceiptsFallsBackToBatchedReceipts(t *testing.T) {
+	node, address := newTestNode(t, 2, 3, false)
+	client, err := New(address, Options{ReceiptBatchSize: 2})
+	require.NoError(t, err)
+
+	for range 2 {
+		receipts, err := client.BlockReceipts(context.Background(), 2)
+		require.NoError(t, err)
+		require.Len(t, receipts, 3)
+		for i, receipt := range receipts {
+			assert.Equal(t, node.txs[2][i].Hash().String(), receipt.TxHash)
+		}
+	}
+
+	receiptBatch := []string{"eth_getTransactionReceipt", "eth_getTransactionReceipt"}
+	assert.Equal(t, [][]string{
+		{"eth_getBlockReceipts"},
+		{"eth_getBlockByNumber"}, receiptBatch, {"eth_getTransactionReceipt"},
+		// eth_getBlockReceipts is not tried again once the node has reported that it does not support it.
+		{"eth_getBlockByNumber"}, receiptBatch, {"eth_getTransactionReceipt"},
+	}, node.calls())
+}
+
+func TestTransactionIsStampedWithBlockTime(t *testing.T) {
+	node, address := newTestNode(t, 3, 2, true)
+	client, err := New(address, Options{})
+	require.NoError(t, err)
+
+	tx, err := client.Transaction(context.Background(), node.txs[2][1].Hash().String())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), tx.BlockNumber)
+	assert.Equal(t, uint(1), tx.Position)
+	assert.Equal(t, time.Unix(1700000000+2*12, 0).UTC(), tx.CreatedAt)
+}
+
+func TestConvertReceiptOfPreByzantiumTransactions(t *testing.T) {
+	gasPrice := big.NewInt(50)
+
+	receipt, err := Client{}.convertReceipt(context.Background(), &types.Receipt{
+		PostState:         common.HexToHash("0x1").Bytes(),
+		EffectiveGasPrice: gasPrice,
+	})
+	require.NoError(t, err)
+	assert.True(t, receipt.Successful)
+
+	receipt, err = Client{}.convertReceipt(context.Background(), &types.Receipt{
+		Status:            types.ReceiptStatusFailed,
+		EffectiveGasPrice: gasPrice,
+	})
+	require.NoError(t, err)
+	assert.False(t, receipt.Successful)
+}
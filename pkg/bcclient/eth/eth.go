@@ -5,15 +5,51 @@ import (
 	"blockbook/pkg/errors"
 	"context"
 	"math/big"
+	"net/http"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
 	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/ethereum/go-ethereum/rpc"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultBlockBatchSize is the number of blocks fetched in a single batch request if Options.BlockBatchSize is not
+	// set.
+	DefaultBlockBatchSize = 20
+	// DefaultReceiptBatchSize is the number of receipts fetched in a single batch request if Options.ReceiptBatchSize
+	// is not set.
+	DefaultReceiptBatchSize = 100
 )
 
 // Client is an implementation of `bcclient.Client` using `go-ethereum` pkg.
 type Client struct {
-	cli *ethclient.Client
+	cli              *ethclient.Client
+	rpc              *rpc.Client
+	metrics          *clientMetrics
+	blockBatchSize   int
+	receiptBatchSize int
+	// blockReceiptsUnsupported is set once the node responds that it does not support eth_getBlockReceipts.
+	blockReceiptsUnsupported *atomic.Bool
+}
+
+// Options contains the configurable parameters of Client.
+type Options struct {
+	// Registerer is used to register the rpc metrics. Metrics are not exported if it's nil.
+	Registerer prometheus.Registerer
+	// BlockBatchSize is the maximum number of blocks which Blocks fetches in a single batch request.
+	BlockBatchSize int
+	// ReceiptBatchSize is the maximum number of receipts which BlockReceipts fetches in a single batch request, When
+	// the node does not support eth_getBlockReceipts.
+	ReceiptBatchSize int
+	// Transport sends the http requests to the node, e.g. a `ratelimitclient.Transport` which throttles them. It's
+	// `http.DefaultTransport` if it's nil.
+	Transport http.RoundTripper
 }
 
 // This piece of code is to ensure that a type implements a certain interface at compile time.
@@ -21,7 +57,9 @@ type Client struct {
 var _ bcclient.Client = (*Client)(nil)
 
 func (c Client) CurrentBlockNumber(ctx context.Context) (uint64, error) {
+	start := time.Now()
 	num, err := c.cli.BlockNumber(ctx)
+	c.metrics.observe("eth_blockNumber", start, err)
 	if err != nil {
 		return 0, errors.Wrap(err, "could not get current block number")
 	}
@@ -30,7 +68,9 @@ func (c Client) CurrentBlockNumber(ctx context.Context) (uint64, error) {
 }
 
 func (c Client) Block(ctx context.Context, number uint64) (bcclient.Block, error) {
+	start := time.Now()
 	block, err := c.cli.BlockByNumber(ctx, big.NewInt(int64(number)))
+	c.metrics.observe("eth_getBlockByNumber", start, err)
 	if err != nil {
 		if errors.Is(err, ethereum.NotFound) {
 			return bcclient.Block{}, bcclient.ErrBlockNotFound
@@ -39,39 +79,218 @@ func (c Client) Block(ctx context.Context, number uint64) (bcclient.Block, error
 		return bcclient.Block{}, errors.Wrap(err, "could not get block by number")
 	}
 
-	txs := make([]*bcclient.Transaction, 0, len(block.Transactions()))
-	for _, tx := range block.Transactions() {
+	return convertBlock(block.NumberU64(), block.Hash(), block.ParentHash(), block.Time(), block.Transactions()), nil
+}
+
+func (c Client) Balance(ctx context.Context, address string, blockNumber uint64) (*big.Int, error) {
+	start := time.Now()
+	balance, err := c.cli.BalanceAt(ctx, common.HexToAddress(address), new(big.Int).SetUint64(blockNumber))
+	c.metrics.observe("eth_getBalance", start, err)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get balance")
+	}
+
+	return balance, nil
+}
+
+func (c Client) Receipt(ctx context.Context, txHash string) (bcclient.Receipt, error) {
+	hash := common.HexToHash(txHash)
+	receipt, err := c.transactionReceipt(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return bcclient.Receipt{}, bcclient.ErrReceiptNotFound
+		}
+
+		return bcclient.Receipt{}, errors.Wrap(err, "could not get transaction receipt")
+	}
+
+	converted, err := c.convertReceipt(ctx, receipt)
+	if err != nil {
+		return bcclient.Receipt{}, err
+	}
+	converted.TxHash = txHash
+
+	return converted, nil
+}
+
+func (c Client) Transaction(ctx context.Context, txHash string) (bcclient.Transaction, error) {
+	hash := common.HexToHash(txHash)
+	tx, isPending, err := c.transactionByHash(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return bcclient.Transaction{}, bcclient.ErrTransactionNotFound
+		}
+
+		return bcclient.Transaction{}, errors.Wrap(err, "could not get transaction by hash")
+	}
+
+	// pending transactions are not part of any block yet.
+	if isPending {
+		return bcclient.Transaction{}, bcclient.ErrTransactionNotFound
+	}
+
+	// the transaction itself does not contain its position in the chain, but the receipt does.
+	receipt, err := c.transactionReceipt(ctx, hash)
+	if err != nil {
+		if errors.Is(err, ethereum.NotFound) {
+			return bcclient.Transaction{}, bcclient.ErrTransactionNotFound
+		}
+
+		return bcclient.Transaction{}, errors.Wrap(err, "could not get transaction receipt")
+	}
+
+	// the transaction is stamped with the time of its block.
+	blockTime, err := c.blockTime(ctx, receipt.BlockNumber.Uint64())
+	if err != nil {
+		return bcclient.Transaction{}, err
+	}
+
+	converted, err := convertTransaction(tx, receipt.BlockNumber.Uint64(), receipt.TransactionIndex, blockTime)
+	if err != nil {
+		return bcclient.Transaction{}, err
+	}
+
+	return *converted, nil
+}
+
+// blockTime returns the timestamp of a block without fetching its transactions.
+func (c Client) blockTime(ctx context.Context, number uint64) (time.Time, error) {
+	var header *rpcBlockHeader
+	start := time.Now()
+	err := c.rpc.CallContext(ctx, &header, "eth_getBlockByNumber", hexutil.EncodeUint64(number), false)
+	c.metrics.observe("eth_getBlockByNumber", start, err)
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "could not get block by number")
+	}
+	if header == nil {
+		return time.Time{}, bcclient.ErrBlockNotFound
+	}
+
+	return unixTime(uint64(header.Timestamp)), nil
+}
+
+func (c Client) transactionReceipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	start := time.Now()
+	receipt, err := c.cli.TransactionReceipt(ctx, hash)
+	c.metrics.observe("eth_getTransactionReceipt", start, err)
+
+	return receipt, err //nolint:wrapcheck
+}
+
+func (c Client) transactionByHash(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	start := time.Now()
+	tx, isPending, err := c.cli.TransactionByHash(ctx, hash)
+	c.metrics.observe("eth_getTransactionByHash", start, err)
+
+	return tx, isPending, err //nolint:wrapcheck
+}
+
+// convertReceipt converts a `go-ethereum` receipt to `bcclient.Receipt`.
+func (c Client) convertReceipt(ctx context.Context, receipt *types.Receipt) (bcclient.Receipt, error) {
+	// pre-London nodes do not return the effective gas price, In that case the gas price of the transaction itself is
+	// what has been paid.
+	gasPrice := receipt.EffectiveGasPrice
+	if gasPrice == nil {
+		tx, _, err := c.transactionByHash(ctx, receipt.TxHash)
+		if err != nil {
+			return bcclient.Receipt{}, errors.Wrap(err, "could not get transaction by hash")
+		}
+		gasPrice = tx.GasPrice()
+	}
+
+	return bcclient.Receipt{
+		TxHash: receipt.TxHash.String(),
+		// pre-Byzantium receipts contain the post state root instead of a status, The outcome of those transactions
+		// can not be told from their receipts, So they are considered successful.
+		Successful:        receipt.Status == types.ReceiptStatusSuccessful || len(receipt.PostState) > 0,
+		GasUsed:           receipt.GasUsed,
+		EffectiveGasPrice: gasPrice,
+	}, nil
+}
+
+// convertBlock converts the fields of a `go-ethereum` block to `bcclient.Block`. Transactions which are not value
+// transfers are skipped.
+func convertBlock(number uint64, hash common.Hash, parentHash common.Hash, timestamp uint64, transactions types.Transactions) bcclient.Block {
+	blockTime := unixTime(timestamp)
+	txs := make([]*bcclient.Transaction, 0, len(transactions))
+	for i, tx := range transactions {
 		if tx.To() == nil || tx.Value() == nil {
 			continue
 		}
 
-		from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+		converted, err := convertTransaction(tx, number, uint(i), blockTime)
 		if err != nil {
 			continue
 		}
 
-		txs = append(txs, &bcclient.Transaction{
-			Hash:        tx.Hash().String(),
-			FromAddress: from.String(),
-			ToAddress:   tx.To().String(),
-			Amount:      tx.Value(),
-			CreatedAt:   tx.Time(),
-		})
+		txs = append(txs, converted)
 	}
 
 	return bcclient.Block{
-		Number:       block.NumberU64(),
-		Transactions: txs,
+		Number:           number,
+		Hash:             hash.String(),
+		ParentHash:       parentHash.String(),
+		Timestamp:        blockTime,
+		TransactionCount: len(transactions),
+		Transactions:     txs,
+	}
+}
+
+// convertTransaction converts a `go-ethereum` transaction to `bcclient.Transaction`. blockTime is the timestamp of the
+// block containing the transaction, The time of the transaction itself is only the time it has been decoded.
+func convertTransaction(tx *types.Transaction, blockNumber uint64, position uint, blockTime time.Time) (*bcclient.Transaction, error) {
+	from, err := types.Sender(types.LatestSignerForChainID(tx.ChainId()), tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not recover transaction sender")
+	}
+
+	// contract creation transactions do not have a receiver.
+	toAddress := ""
+	if tx.To() != nil {
+		toAddress = tx.To().String()
+	}
+
+	return &bcclient.Transaction{
+		Hash:        tx.Hash().String(),
+		FromAddress: from.String(),
+		ToAddress:   toAddress,
+		Amount:      tx.Value(),
+		BlockNumber: blockNumber,
+		Position:    position,
+		CreatedAt:   blockTime,
 	}, nil
 }
 
-func New(rpcAddress string) (Client, error) {
-	cli, err := ethclient.Dial(rpcAddress)
+// unixTime converts the timestamp of a block header to time.Time.
+func unixTime(timestamp uint64) time.Time {
+	return time.Unix(int64(timestamp), 0).UTC()
+}
+
+func New(rpcAddress string, options Options) (Client, error) {
+	if options.Transport == nil {
+		options.Transport = http.DefaultTransport
+	}
+	httpClient := &http.Client{
+		Transport: &rateLimitTransport{base: options.Transport},
+	}
+	rpcClient, err := rpc.DialOptions(context.Background(), rpcAddress, rpc.WithHTTPClient(httpClient))
 	if err != nil {
 		return Client{}, errors.Wrap(err, "could not create eth rpc client")
 	}
 
+	if options.BlockBatchSize <= 0 {
+		options.BlockBatchSize = DefaultBlockBatchSize
+	}
+	if options.ReceiptBatchSize <= 0 {
+		options.ReceiptBatchSize = DefaultReceiptBatchSize
+	}
+
 	return Client{
-		cli: cli,
+		cli:                      ethclient.NewClient(rpcClient),
+		rpc:                      rpcClient,
+		metrics:                  newClientMetrics(options.Registerer),
+		blockBatchSize:           options.BlockBatchSize,
+		receiptBatchSize:         options.ReceiptBatchSize,
+		blockReceiptsUnsupported: new(atomic.Bool),
 	}, nil
 }
@@ -0,0 +1,47 @@
+package ethclient
+
+import (
+	"blockbook/pkg/errors"
+	"blockbook/pkg/metrics"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsSubsystem = "rpc"
+)
+
+// clientMetrics contains the prometheus metrics of the rpc calls, partitioned by their json-rpc method.
+type clientMetrics struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// observe records the latency of a rpc call which has been started at start. Not found responses are not counted as
+// errors since they are expected.
+func (m *clientMetrics) observe(method string, start time.Time, err error) {
+	m.duration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+	if err != nil && !errors.Is(err, ethereum.NotFound) {
+		m.errors.WithLabelValues(method).Inc()
+	}
+}
+
+func newClientMetrics(registerer prometheus.Registerer) *clientMetrics {
+	return &clientMetrics{
+		duration: metrics.Register(registerer, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the rpc requests sent to the node, partitioned by method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method"})),
+		errors: metrics.Register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "request_errors_total",
+			Help:      "How many rpc requests sent to the node have failed, partitioned by method.",
+		}, []string{"method"})),
+	}
+}
@@ -0,0 +1,32 @@
+package ethclient
+
+import (
+	"blockbook/pkg/bcclient"
+	"io"
+	"net/http"
+	"time"
+)
+
+// rateLimitTransport turns the 429 responses of the node into `bcclient.RateLimitedError`, Since `go-ethereum` drops
+// the Retry-After header of failed responses.
+type rateLimitTransport struct {
+	base http.RoundTripper
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err //nolint:wrapcheck
+	}
+
+	if resp.StatusCode != http.StatusTooManyRequests {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	_ = resp.Body.Close()
+
+	return nil, &bcclient.RateLimitedError{
+		RetryAfter: bcclient.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
@@ -0,0 +1,383 @@
+package fakeclient
+
+import (
+	"blockbook/pkg/bcclient"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+)
+
+const (
+	// BlockTime is the time between the timestamps of two consecutive blocks.
+	BlockTime = 12 * time.Second
+	// DefaultGasUsed is the gas used by the transactions unless their receipt is scripted using SetReceipt.
+	DefaultGasUsed = 21000
+	// DefaultGasPrice is the gas price paid by the transactions unless their receipt is scripted using SetReceipt.
+	DefaultGasPrice = 1000000000
+)
+
+// Method is a method of `bcclient.Client`, It's used to script errors and delays of the calls.
+type Method string
+
+const (
+	MethodCurrentBlockNumber Method = "CurrentBlockNumber"
+	MethodBlock              Method = "Block"
+	MethodBlocks             Method = "Blocks"
+	MethodBalance            Method = "Balance"
+	MethodReceipt            Method = "Receipt"
+	MethodBlockReceipts      Method = "BlockReceipts"
+	MethodTransaction        Method = "Transaction"
+)
+
+// Client is an in-memory implementation of `bcclient.Client` backed by a scriptable chain. It is meant to be used in
+// tests: blocks are appended on demand, errors and delays can be injected per method and the chain can be reorganized.
+// Balances are derived from an initial balance and the transactions of the chain.
+type Client struct {
+	mu sync.Mutex
+	// blocks contains the canonical chain, The index of each block is its number.
+	blocks []bcclient.Block
+	// receipts contains the scripted receipts by transaction hash, Other transactions succeed with the default gas.
+	receipts        map[string]bcclient.Receipt
+	initialBalances map[string]*big.Int
+	errors          map[Method][]error
+	delays          map[Method]time.Duration
+	calls           map[Method]int
+	// fork is incremented on every reorg, so re-mined blocks get different hashes.
+	fork    int
+	genesis time.Time
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ bcclient.Client = (*Client)(nil)
+
+func (c *Client) CurrentBlockNumber(ctx context.Context) (uint64, error) {
+	if err := c.before(ctx, MethodCurrentBlockNumber); err != nil {
+		return 0, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return uint64(len(c.blocks) - 1), nil
+}
+
+func (c *Client) Block(ctx context.Context, number uint64) (bcclient.Block, error) {
+	if err := c.before(ctx, MethodBlock); err != nil {
+		return bcclient.Block{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if number >= uint64(len(c.blocks)) {
+		return bcclient.Block{}, bcclient.ErrBlockNotFound
+	}
+
+	return c.blocks[number].Clone(), nil
+}
+
+func (c *Client) Blocks(ctx context.Context, from uint64, to uint64) ([]bcclient.Block, error) {
+	if err := c.before(ctx, MethodBlocks); err != nil {
+		return nil, err
+	}
+
+	if from > to {
+		return nil, bcclient.ErrInvalidBlockRange
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if to >= uint64(len(c.blocks)) {
+		return nil, bcclient.ErrBlockNotFound
+	}
+
+	blocks := make([]bcclient.Block, 0, to-from+1)
+	for _, block := range c.blocks[from : to+1] {
+		blocks = append(blocks, block.Clone())
+	}
+
+	return blocks, nil
+}
+
+func (c *Client) Balance(ctx context.Context, address string, blockNumber uint64) (*big.Int, error) {
+	if err := c.before(ctx, MethodBalance); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if blockNumber >= uint64(len(c.blocks)) {
+		return nil, bcclient.ErrBlockNotFound
+	}
+
+	balance := new(big.Int)
+	if initial, ok := c.initialBalances[address]; ok {
+		balance.Set(initial)
+	}
+
+	for _, block := range c.blocks[:blockNumber+1] {
+		for _, tx := range block.Transactions {
+			receipt := c.receipt(tx.Hash)
+			if tx.FromAddress == address {
+				balance.Sub(balance, receipt.Fee())
+				if receipt.Successful {
+					balance.Sub(balance, tx.Amount)
+				}
+			}
+			if tx.ToAddress == address && receipt.Successful {
+				balance.Add(balance, tx.Amount)
+			}
+		}
+	}
+
+	return balance, nil
+}
+
+func (c *Client) Receipt(ctx context.Context, txHash string) (bcclient.Receipt, error) {
+	if err := c.before(ctx, MethodReceipt); err != nil {
+		return bcclient.Receipt{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.findTransaction(txHash); !ok {
+		return bcclient.Receipt{}, bcclient.ErrReceiptNotFound
+	}
+
+	return c.receipt(txHash), nil
+}
+
+func (c *Client) BlockReceipts(ctx context.Context, number uint64) ([]bcclient.Receipt, error) {
+	if err := c.before(ctx, MethodBlockReceipts); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if number >= uint64(len(c.blocks)) {
+		return nil, bcclient.ErrBlockNotFound
+	}
+
+	receipts := make([]bcclient.Receipt, 0, len(c.blocks[number].Transactions))
+	for _, tx := range c.blocks[number].Transactions {
+		receipts = append(receipts, c.receipt(tx.Hash))
+	}
+
+	return receipts, nil
+}
+
+func (c *Client) Transaction(ctx context.Context, txHash string) (bcclient.Transaction, error) {
+	if err := c.before(ctx, MethodTransaction); err != nil {
+		return bcclient.Transaction{}, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	tx, ok := c.findTransaction(txHash)
+	if !ok {
+		return bcclient.Transaction{}, bcclient.ErrTransactionNotFound
+	}
+
+	return *tx, nil
+}
+
+// AppendBlock mines a new block on top of the chain containing the given transactions. Block number, position and
+// creation time of the transactions are set, Alongside their hash if it's empty. A copy of the block is returned.
+func (c *Client) AppendBlock(txs ...*bcclient.Transaction) bcclient.Block {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	number := uint64(len(c.blocks))
+	block := bcclient.Block{
+		Number:           number,
+		Hash:             fakeHash("block", c.fork, number),
+		ParentHash:       c.blocks[number-1].Hash,
+		Timestamp:        c.genesis.Add(time.Duration(number) * BlockTime),
+		TransactionCount: len(txs),
+		Transactions:     make([]*bcclient.Transaction, 0, len(txs)),
+	}
+
+	for i, tx := range txs {
+		stored := *tx
+		if stored.Hash == "" {
+			stored.Hash = fakeHash("tx", c.fork, number, i)
+		}
+		stored.BlockNumber = number
+		stored.Position = uint(i)
+		stored.CreatedAt = block.Timestamp
+		block.Transactions = append(block.Transactions, &stored)
+	}
+
+	c.blocks = append(c.blocks, block)
+
+	return block.Clone()
+}
+
+// AppendBlocks mines n empty blocks and returns the new chain head.
+func (c *Client) AppendBlocks(n int) uint64 {
+	for range n {
+		c.AppendBlock()
+	}
+
+	return c.Head()
+}
+
+// Reorg drops the latest depth blocks of the chain, Blocks appended afterward get different hashes than the dropped
+// ones. The genesis block can not be dropped.
+func (c *Client) Reorg(depth int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	depth = min(depth, len(c.blocks)-1)
+	c.blocks = c.blocks[:len(c.blocks)-depth]
+	c.fork++
+}
+
+// Head returns the number of the latest block of the chain.
+func (c *Client) Head() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return uint64(len(c.blocks) - 1)
+}
+
+// SetReceipt scripts the receipt of a transaction, e.g. to make it fail.
+func (c *Client) SetReceipt(receipt bcclient.Receipt) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.receipts[receipt.TxHash] = receipt
+}
+
+// SetInitialBalance sets the balance of an address at the genesis block.
+func (c *Client) SetInitialBalance(address string, amount *big.Int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.initialBalances[address] = new(big.Int).Set(amount)
+}
+
+// InjectError makes the next call of a method fail with err. Multiple errors are returned by consecutive calls in the
+// same order they have been injected.
+func (c *Client) InjectError(method Method, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.errors[method] = append(c.errors[method], err)
+}
+
+// SetDelay makes every call of a method wait for the given duration, Or until its context is done. Zero removes the
+// delay.
+func (c *Client) SetDelay(method Method, delay time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.delays[method] = delay
+}
+
+// Calls returns the number of times a method has been called, Including the failed calls.
+func (c *Client) Calls(method Method) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.calls[method]
+}
+
+// before records a call of method and applies its scripted delay and error.
+func (c *Client) before(ctx context.Context, method Method) error {
+	c.mu.Lock()
+	c.calls[method]++
+	delay := c.delays[method]
+	var err error
+	if errs := c.errors[method]; len(errs) > 0 {
+		err = errs[0]
+		c.errors[method] = errs[1:]
+	}
+	c.mu.Unlock()
+
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
+		case <-timer.C:
+		}
+	}
+
+	return err
+}
+
+// receipt returns the scripted receipt of a transaction or the default one. c.mu must be held by the caller.
+func (c *Client) receipt(txHash string) bcclient.Receipt {
+	if receipt, ok := c.receipts[txHash]; ok {
+		return receipt
+	}
+
+	return bcclient.Receipt{
+		TxHash:            txHash,
+		Successful:        true,
+		GasUsed:           DefaultGasUsed,
+		EffectiveGasPrice: big.NewInt(DefaultGasPrice),
+	}
+}
+
+// findTransaction looks up a transaction of the canonical chain. c.mu must be held by the caller.
+func (c *Client) findTransaction(txHash string) (*bcclient.Transaction, bool) {
+	for _, block := range c.blocks {
+		for _, tx := range block.Transactions {
+			if tx.Hash == txHash {
+				return tx, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// NewTransaction returns a transfer which can be passed to AppendBlock.
+func NewTransaction(from string, to string, amount *big.Int) *bcclient.Transaction {
+	return &bcclient.Transaction{
+		FromAddress: from,
+		ToAddress:   to,
+		Amount:      amount,
+	}
+}
+
+// fakeHash returns a deterministic 32 bytes hex encoded hash of the given parts.
+func fakeHash(parts ...any) string {
+	hash := sha256.Sum256([]byte(fmt.Sprintf("%v", parts)))
+
+	return "0x" + hex.EncodeToString(hash[:])
+}
+
+// New creates a chain which only contains the genesis block.
+func New() *Client {
+	genesis := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	return &Client{
+		blocks: []bcclient.Block{{
+			Number:       0,
+			Hash:         fakeHash("block", 0, 0),
+			Timestamp:    genesis,
+			Transactions: make([]*bcclient.Transaction, 0),
+		}},
+		receipts:        make(map[string]bcclient.Receipt),
+		initialBalances: make(map[string]*big.Int),
+		errors:          make(map[Method][]error),
+		delays:          make(map[Method]time.Duration),
+		calls:           make(map[Method]int),
+		genesis:         genesis,
+	}
+}
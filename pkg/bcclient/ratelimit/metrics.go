@@ -0,0 +1,41 @@
+package ratelimitclient
+
+import (
+	"blockbook/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsSubsystem = "rpc"
+)
+
+type throttleMetrics struct {
+	waitDuration prometheus.Histogram
+	inFlight     prometheus.Gauge
+	rateLimited  prometheus.Counter
+}
+
+func newThrottleMetrics(registerer prometheus.Registerer) *throttleMetrics {
+	return &throttleMetrics{
+		waitDuration: metrics.Register(registerer, prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "throttle_wait_duration_seconds",
+			Help:      "How long the rpc requests have waited for the client-side rate limits before being sent.",
+			Buckets:   prometheus.DefBuckets,
+		})),
+		inFlight: metrics.Register(registerer, prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "in_flight_requests",
+			Help:      "Number of the rpc requests which are being sent to the node.",
+		})),
+		rateLimited: metrics.Register(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "rate_limited_requests_total",
+			Help:      "How many rpc requests have been rejected by the node with 429 status code.",
+		})),
+	}
+}
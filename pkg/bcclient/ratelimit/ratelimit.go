@@ -0,0 +1,162 @@
+package ratelimitclient
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/errors"
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// DefaultRetryAfter is how long requests are held back after the node has rate limited a request without
+	// specifying a Retry-After.
+	DefaultRetryAfter = time.Second
+)
+
+type Options struct {
+	// RequestsPerSecond is the rate which the token bucket is refilled with, Zero disables the limit.
+	RequestsPerSecond float64
+	// Burst is the size of the token bucket.
+	Burst int
+	// MaxConcurrency is the maximum number of in-flight requests, Zero means unlimited.
+	MaxConcurrency int
+	// MaxRetries is the number of times a request rate limited by the node is retried after its Retry-After.
+	MaxRetries int
+	// Registerer is used to register the throttling metrics, They are not exported if it's nil.
+	Registerer prometheus.Registerer
+}
+
+// Transport is an `http.RoundTripper` which throttles the http requests sent to the node. It sits below the rpc client,
+// So every http request counts against the limits, Including each request of a client method which sends several of
+// them and each json-rpc batch. Requests wait for a token of the bucket and a concurrency slot before being sent. When
+// the node rate limits a request, All requests are held back until its Retry-After has passed and the request is
+// retried. The 429 response is returned once the retries are exhausted.
+type Transport struct {
+	base       http.RoundTripper
+	limiter    *rate.Limiter
+	slots      chan struct{}
+	maxRetries int
+	// pausedUntil is the unix nano time which requests are held back until.
+	pausedUntil atomic.Int64
+	metrics     *throttleMetrics
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ http.RoundTripper = (*Transport)(nil)
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		release, err := t.acquire(req.Context())
+		if err != nil {
+			return nil, err
+		}
+
+		t.metrics.inFlight.Inc()
+		resp, err := t.base.RoundTrip(req)
+		t.metrics.inFlight.Dec()
+		release()
+
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests {
+			return resp, err //nolint:wrapcheck
+		}
+
+		t.metrics.rateLimited.Inc()
+		t.pause(bcclient.ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()))
+		if attempt >= t.maxRetries || req.GetBody == nil {
+			return resp, nil
+		}
+
+		body, err := req.GetBody()
+		if err != nil {
+			return resp, nil //nolint:nilerr
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+
+		req = req.Clone(req.Context())
+		req.Body = body
+	}
+}
+
+// acquire waits until a request can be sent and returns a function which must be called once it's done.
+func (t *Transport) acquire(ctx context.Context) (func(), error) {
+	start := time.Now()
+
+	if wait := time.Until(time.Unix(0, t.pausedUntil.Load())); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return nil, ctx.Err() //nolint:wrapcheck
+		case <-timer.C:
+		}
+	}
+
+	if t.limiter != nil {
+		if err := t.limiter.Wait(ctx); err != nil {
+			return nil, errors.Wrap(err, "could not wait for rate limiter")
+		}
+	}
+
+	release := func() {}
+	if t.slots != nil {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err() //nolint:wrapcheck
+		case t.slots <- struct{}{}:
+		}
+		release = func() {
+			<-t.slots
+		}
+	}
+
+	t.metrics.waitDuration.Observe(time.Since(start).Seconds())
+
+	return release, nil
+}
+
+// pause holds back all requests for retryAfter, Or DefaultRetryAfter if it's zero.
+func (t *Transport) pause(retryAfter time.Duration) {
+	if retryAfter <= 0 {
+		retryAfter = DefaultRetryAfter
+	}
+
+	until := time.Now().Add(retryAfter).UnixNano()
+	for {
+		current := t.pausedUntil.Load()
+		if until <= current || t.pausedUntil.CompareAndSwap(current, until) {
+			return
+		}
+	}
+}
+
+// NewTransport wraps base, Which is `http.DefaultTransport` if it's nil.
+func NewTransport(base http.RoundTripper, options Options) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	t := &Transport{
+		base:       base,
+		maxRetries: options.MaxRetries,
+		metrics:    newThrottleMetrics(options.Registerer),
+	}
+
+	if options.RequestsPerSecond > 0 {
+		t.limiter = rate.NewLimiter(rate.Limit(options.RequestsPerSecond), max(options.Burst, 1))
+	}
+
+	if options.MaxConcurrency > 0 {
+		t.slots = make(chan struct{}, options.MaxConcurrency)
+	}
+
+	return t
+}
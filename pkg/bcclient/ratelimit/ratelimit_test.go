@@ -0,0 +1,149 @@
+package ratelimitclient
+
+import (
+	"blockbook/pkg/bcclient"
+	ethclient "blockbook/pkg/bcclient/eth"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRateLimitedNode starts a json-rpc node which rate limits the first rateLimited requests with the given
+// Retry-After header, And responds to the next eth_blockNumber requests with blockNumber. The address of the node and
+// the number of received requests are returned.
+func newRateLimitedNode(t *testing.T, rateLimited int32, retryAfter string, blockNumber uint64) (string, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= rateLimited {
+			w.Header().Set("Retry-After", retryAfter)
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		var req struct {
+			ID json.RawMessage `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%s,"result":"0x%x"}`, req.ID, blockNumber)
+	}))
+	t.Cleanup(server.Close)
+
+	return server.URL, &requests
+}
+
+func newThrottledClient(t *testing.T, address string, options Options) (ethclient.Client, *Transport) {
+	t.Helper()
+
+	transport := NewTransport(nil, options)
+	client, err := ethclient.New(address, ethclient.Options{Transport: transport})
+	require.NoError(t, err)
+
+	return client, transport
+}
+
+func TestRetriesAfterNodeRateLimits(t *testing.T) {
+	address, requests := newRateLimitedNode(t, 1, "1", 16)
+	client, transport := newThrottledClient(t, address, Options{MaxRetries: 1})
+
+	start := time.Now()
+	num, err := client.CurrentBlockNumber(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, uint64(16), num)
+	assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	assert.Equal(t, int32(2), requests.Load())
+	assert.Equal(t, float64(1), testutil.ToFloat64(transport.metrics.rateLimited))
+}
+
+func TestReturnsRateLimitedErrorWhenRetriesAreExhausted(t *testing.T) {
+	address, requests := newRateLimitedNode(t, 1, "0", 16)
+	client, _ := newThrottledClient(t, address, Options{})
+
+	_, err := client.CurrentBlockNumber(context.Background())
+	var rateLimited *bcclient.RateLimitedError
+	require.ErrorAs(t, err, &rateLimited)
+	assert.Equal(t, time.Duration(0), rateLimited.RetryAfter)
+	assert.Equal(t, int32(1), requests.Load())
+}
+
+func TestLimitsEveryHTTPRequest(t *testing.T) {
+	const (
+		requests = 6
+		delay    = 50 * time.Millisecond
+	)
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			previous := maxInFlight.Load()
+			if current <= previous || maxInFlight.CompareAndSwap(previous, current) {
+				break
+			}
+		}
+		time.Sleep(delay)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(server.Close)
+
+	transport := NewTransport(nil, Options{
+		RequestsPerSecond: 100,
+		Burst:             requests,
+		MaxConcurrency:    2,
+	})
+	httpClient := &http.Client{Transport: transport}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for range requests {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			resp, err := httpClient.Get(server.URL)
+			if assert.NoError(t, err) {
+				_ = resp.Body.Close()
+			}
+		}()
+	}
+	wg.Wait()
+
+	// requests are sent two at a time.
+	assert.GreaterOrEqual(t, time.Since(start), requests/2*delay)
+	assert.Equal(t, int32(2), maxInFlight.Load())
+}
+
+func TestTakesATokenPerHTTPRequest(t *testing.T) {
+	address, requests := newRateLimitedNode(t, 0, "", 16)
+	client, _ := newThrottledClient(t, address, Options{RequestsPerSecond: 20, Burst: 1})
+
+	start := time.Now()
+	for range 5 {
+		_, err := client.CurrentBlockNumber(context.Background())
+		require.NoError(t, err)
+	}
+
+	// the first request takes the only token of the bucket, The next ones wait 50ms each.
+	assert.GreaterOrEqual(t, time.Since(start), 4*50*time.Millisecond)
+	assert.Equal(t, int32(5), requests.Load())
+}
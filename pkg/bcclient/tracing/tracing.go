@@ -0,0 +1,98 @@
+package tracingclient
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/tracing"
+	"context"
+	"math/big"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	tracerName = "blockbook/pkg/bcclient"
+)
+
+// Client is a decorator of `bcclient.Client` which wraps every call in a span.
+type Client struct {
+	client bcclient.Client
+	tracer trace.Tracer
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ bcclient.Client = (*Client)(nil)
+
+func (c *Client) CurrentBlockNumber(ctx context.Context) (uint64, error) {
+	ctx, span := c.tracer.Start(ctx, "bcclient.CurrentBlockNumber", trace.WithSpanKind(trace.SpanKindClient))
+	num, err := c.client.CurrentBlockNumber(ctx)
+	span.SetAttributes(attribute.Int64("block.number", int64(num)))
+	tracing.End(span, err)
+
+	return num, err
+}
+
+func (c *Client) Block(ctx context.Context, number uint64) (bcclient.Block, error) {
+	ctx, span := c.tracer.Start(ctx, "bcclient.Block", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("block.number", int64(number))))
+	block, err := c.client.Block(ctx, number)
+	span.SetAttributes(attribute.Int("block.transactions", block.TransactionCount))
+	tracing.End(span, err)
+
+	return block, err
+}
+
+func (c *Client) Blocks(ctx context.Context, from uint64, to uint64) ([]bcclient.Block, error) {
+	ctx, span := c.tracer.Start(ctx, "bcclient.Blocks", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("block.from", int64(from)), attribute.Int64("block.to", int64(to))))
+	blocks, err := c.client.Blocks(ctx, from, to)
+	tracing.End(span, err)
+
+	return blocks, err
+}
+
+func (c *Client) Balance(ctx context.Context, address string, blockNumber uint64) (*big.Int, error) {
+	ctx, span := c.tracer.Start(ctx, "bcclient.Balance", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("address", address), attribute.Int64("block.number", int64(blockNumber))))
+	balance, err := c.client.Balance(ctx, address, blockNumber)
+	tracing.End(span, err)
+
+	return balance, err
+}
+
+func (c *Client) Receipt(ctx context.Context, txHash string) (bcclient.Receipt, error) {
+	ctx, span := c.tracer.Start(ctx, "bcclient.Receipt", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("tx.hash", txHash)))
+	receipt, err := c.client.Receipt(ctx, txHash)
+	tracing.End(span, err)
+
+	return receipt, err
+}
+
+func (c *Client) BlockReceipts(ctx context.Context, number uint64) ([]bcclient.Receipt, error) {
+	ctx, span := c.tracer.Start(ctx, "bcclient.BlockReceipts", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int64("block.number", int64(number))))
+	receipts, err := c.client.BlockReceipts(ctx, number)
+	span.SetAttributes(attribute.Int("block.receipts", len(receipts)))
+	tracing.End(span, err)
+
+	return receipts, err
+}
+
+func (c *Client) Transaction(ctx context.Context, txHash string) (bcclient.Transaction, error) {
+	ctx, span := c.tracer.Start(ctx, "bcclient.Transaction", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("tx.hash", txHash)))
+	tx, err := c.client.Transaction(ctx, txHash)
+	tracing.End(span, err)
+
+	return tx, err
+}
+
+// New wraps client, Spans are created using the global tracer provider.
+func New(client bcclient.Client) *Client {
+	return &Client{
+		client: client,
+		tracer: tracing.Tracer(tracerName),
+	}
+}
@@ -0,0 +1,67 @@
+package tracingclient
+
+import (
+	fakeclient "blockbook/pkg/bcclient/fake"
+	"blockbook/pkg/errors"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// useSpanRecorder makes the tracers created by the test record their spans into the returned recorder.
+func useSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+	})
+
+	return recorder
+}
+
+func TestCallsAreTraced(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	fake := fakeclient.New()
+	head := fake.AppendBlocks(2)
+	client := New(fake)
+
+	ctx, parent := otel.Tracer("test").Start(context.Background(), "parent")
+	_, err := client.Block(ctx, head)
+	require.NoError(t, err)
+
+	errRPC := errors.New("rpc is down")
+	fake.InjectError(fakeclient.MethodBlocks, errRPC)
+	_, err = client.Blocks(ctx, 1, head)
+	require.ErrorIs(t, err, errRPC)
+	parent.End()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+
+	block, blocks := spans[0], spans[1]
+	assert.Equal(t, "bcclient.Block", block.Name())
+	assert.Equal(t, trace.SpanKindClient, block.SpanKind())
+	assert.Contains(t, block.Attributes(), attribute.Int64("block.number", int64(head)))
+	assert.Equal(t, codes.Unset, block.Status().Code)
+
+	assert.Equal(t, "bcclient.Blocks", blocks.Name())
+	assert.Equal(t, codes.Error, blocks.Status().Code)
+	assert.Equal(t, errRPC.Error(), blocks.Status().Description)
+
+	for _, span := range spans[:2] {
+		assert.Equal(t, parent.SpanContext().SpanID(), span.Parent().SpanID(), span.Name())
+		assert.Equal(t, parent.SpanContext().TraceID(), span.SpanContext().TraceID(), span.Name())
+	}
+}
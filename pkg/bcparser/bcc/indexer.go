@@ -0,0 +1,216 @@
+package bccparser
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/errors"
+	"blockbook/pkg/tracing"
+	"context"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// blockRange is an inclusive range of block numbers.
+type blockRange struct {
+	from uint64
+	to   uint64
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ bcparser.Indexer = (*Parser)(nil)
+
+func (p *Parser) Pause() {
+	if p.paused.CompareAndSwap(false, true) {
+		p.logger.Info("indexing paused")
+	}
+}
+
+func (p *Parser) Resume() {
+	if p.paused.CompareAndSwap(true, false) {
+		// the indexer has not been expected to make progress while paused.
+		p.lastProgressAt.Store(time.Now().UnixNano())
+		p.logger.Info("indexing resumed")
+	}
+}
+
+func (p *Parser) SetIndexInterval(interval time.Duration) error {
+	if interval <= 0 {
+		return bcparser.ErrInvalidIndexInterval
+	}
+
+	p.indexInterval.Store(int64(interval))
+	select {
+	case p.intervalSignal <- struct{}{}:
+	default:
+		// the indexer goroutine has not picked up the previous change yet, It will read the latest interval anyway.
+	}
+	p.logger.Sugar().Infof("index interval changed to %s", interval)
+
+	return nil
+}
+
+func (p *Parser) Reindex(from uint64, to uint64) error {
+	if from > to || to > p.lastIndexedBlock.Load() || to-from >= MaxBlocksToReindex {
+		return bcparser.ErrInvalidBlockRange
+	}
+
+	if !p.reindexing.CompareAndSwap(false, true) {
+		return bcparser.ErrReindexInProgress
+	}
+
+	p.lastReindex.Store(&bcparser.ReindexStatus{
+		From:    from,
+		To:      to,
+		Current: from,
+		Running: true,
+	})
+	// reindexing guarantees the channel is empty, so this never blocks.
+	p.reindexChan <- blockRange{from: from, to: to}
+
+	return nil
+}
+
+// Backfill re-indexes an already indexed block range like Reindex, But it waits for the re-index to finish and is not
+// limited to MaxBlocksToReindex blocks. It is meant to build the histories of new subscriptions offline, e.g. before
+// taking a snapshot.
+func (p *Parser) Backfill(ctx context.Context, from uint64, to uint64) error {
+	if from > to || to > p.lastIndexedBlock.Load() {
+		return bcparser.ErrInvalidBlockRange
+	}
+
+	if !p.reindexing.CompareAndSwap(false, true) {
+		return bcparser.ErrReindexInProgress
+	}
+	defer p.reindexing.Store(false)
+
+	return p.reindex(ctx, blockRange{from: from, to: to})
+}
+
+func (p *Parser) IndexerInfo() bcparser.IndexerInfo {
+	info := bcparser.IndexerInfo{
+		Status:        p.Status(),
+		Paused:        p.paused.Load(),
+		IndexInterval: time.Duration(p.indexInterval.Load()),
+	}
+
+	if status := p.lastReindex.Load(); status != nil {
+		statusCopy := *status
+		info.LastReindex = &statusCopy
+	}
+
+	return info
+}
+
+// startReindexing launches the re-indexer goroutine which re-indexes the requested block ranges one at a time.
+func (p *Parser) startReindexing(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case r := <-p.reindexChan:
+			// failures are reported by the status of the re-index.
+			_ = p.reindex(ctx, r)
+			p.reindexing.Store(false)
+		}
+	}
+}
+
+func (p *Parser) reindex(ctx context.Context, r blockRange) error {
+	p.logger.Sugar().Infof("re-indexing blocks %d to %d...", r.from, r.to)
+
+	status := bcparser.ReindexStatus{
+		From:    r.from,
+		To:      r.to,
+		Running: true,
+	}
+	fail := func(number uint64, err error) error {
+		p.logger.Error("could not re-index block", zap.Uint64("blockNumber", number), zap.Error(err))
+
+		status.Running = false
+		status.Error = err.Error()
+		p.storeReindexStatus(status)
+
+		return err
+	}
+
+	for from := r.from; from <= r.to; {
+		to := min(from+p.blockBatchSize-1, r.to)
+		status.Current = from
+		p.storeReindexStatus(status)
+
+		blocks, err := p.client.Blocks(ctx, from, to)
+		if err != nil {
+			return fail(from, errors.Wrap(err, "could not get blocks from client"))
+		}
+
+		for _, block := range blocks {
+			status.Current = block.Number
+			p.storeReindexStatus(status)
+
+			if err := p.reindexBlock(ctx, block); err != nil {
+				return fail(block.Number, err)
+			}
+		}
+
+		from = to + 1
+	}
+
+	status.Running = false
+	p.storeReindexStatus(status)
+	p.logger.Sugar().Infof("blocks %d to %d re-indexed", r.from, r.to)
+
+	return nil
+}
+
+func (p *Parser) storeReindexStatus(status bcparser.ReindexStatus) {
+	p.lastReindex.Store(&status)
+}
+
+// reindexBlock replaces the indexed transactions of a block with the watched transactions fetched from the client
+// again, So transactions missing from the histories are added and the ones which are not in the block anymore are
+// dropped. Stats are updated accordingly and the balances of the affected addresses are fetched again.
+func (p *Parser) reindexBlock(ctx context.Context, block bcclient.Block) (err error) {
+	ctx, span := p.tracer.Start(ctx, "bccparser.reindexBlock", trace.WithAttributes(attribute.Int64("block.number", int64(block.Number))))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	watchlist := p.subscriptions.watchlist()
+	watchedTxs := filterWatchedTransactions(block.Transactions, watchlist)
+
+	receipts, err := p.fetchReceipts(ctx, block, watchedTxs)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	affected := p.dropBlockTransactions(block.Number)
+	for _, tx := range watchedTxs {
+		p.indexTransaction(tx, receipts[tx.Hash].Successful, watchlist)
+		affected = append(affected, tx.FromAddress, tx.ToAddress)
+	}
+
+	// details of blocks older than the kept ones would never be dropped.
+	if block.Number+MaxBlocksToKeep > p.lastIndexedBlock.Load() {
+		p.storeBlock(block, watchedTxs)
+	}
+	p.mu.Unlock()
+
+	balances := make([]string, 0, len(affected))
+	for _, address := range affected {
+		if _, ok := watchlist[address]; ok && !slices.Contains(balances, address) {
+			balances = append(balances, address)
+		}
+	}
+	if len(balances) > 0 {
+		p.requestBalances(balances)
+	}
+
+	return nil
+}
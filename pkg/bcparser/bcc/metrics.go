@@ -0,0 +1,66 @@
+package bccparser
+
+import (
+	"blockbook/pkg/metrics"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsSubsystem = "indexer"
+
+	retryOperationScan    = "scan"
+	retryOperationBalance = "balance"
+)
+
+// parserMetrics contains the prometheus metrics of the indexer. Gauges are evaluated when metrics are collected.
+type parserMetrics struct {
+	indexedBlocks       prometheus.Counter
+	matchedTransactions prometheus.Counter
+	retries             *prometheus.CounterVec
+}
+
+func newParserMetrics(registerer prometheus.Registerer, p *Parser) *parserMetrics {
+	gaugeFunc := func(name, help string, value func() float64) {
+		metrics.Register(registerer, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      name,
+			Help:      help,
+		}, value))
+	}
+
+	gaugeFunc("chain_head", "The latest block number of the chain as seen by the indexer.", func() float64 {
+		return float64(p.chainHead.Load())
+	})
+	gaugeFunc("last_indexed_block", "The latest indexed block number.", func() float64 {
+		return float64(p.lastIndexedBlock.Load())
+	})
+	gaugeFunc("lag_blocks", "The number of blocks which the indexer is behind the chain head.", func() float64 {
+		return float64(p.Status().Lag)
+	})
+	gaugeFunc("watchlist_size", "The number of distinct addresses watched by all tenants.", func() float64 {
+		return float64(p.subscriptions.addresses.Len())
+	})
+
+	return &parserMetrics{
+		indexedBlocks: metrics.Register(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "indexed_blocks_total",
+			Help:      "How many blocks have been indexed. Its rate is the indexing speed in blocks per second.",
+		})),
+		matchedTransactions: metrics.Register(registerer, prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "matched_transactions_total",
+			Help:      "How many transactions of the indexed blocks have involved a watched address.",
+		})),
+		retries: metrics.Register(registerer, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metrics.Namespace,
+			Subsystem: metricsSubsystem,
+			Name:      "retries_total",
+			Help:      "How many times a failed operation has been retried, partitioned by operation.",
+		}, []string{"operation"})),
+	}
+}
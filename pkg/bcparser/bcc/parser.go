@@ -6,7 +6,13 @@ import (
 	"blockbook/pkg/errors"
 	"blockbook/pkg/logging"
 	"blockbook/pkg/set"
+	"blockbook/pkg/tracing"
+	"cmp"
 	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -14,6 +20,9 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
@@ -22,18 +31,112 @@ const (
 	BackoffMaxElapsedTime = 60 * time.Second
 
 	MaxTxsToKeep = 100
+	// MaxBlocksToKeep is the number of recently indexed blocks which their details are kept in-memory.
+	MaxBlocksToKeep = 1000
+
+	// MaxBalanceFetchAttempts is the number of times fetching the balance of an address is retried when new blocks get
+	// indexed while the balance is being fetched.
+	MaxBalanceFetchAttempts = 3
+
+	// MaxBlocksToReindex is the maximum size of a block range which can be re-indexed at once.
+	MaxBlocksToReindex = 10000
+
+	// DefaultBlockBatchSize is the number of blocks fetched at once if Options.BlockBatchSize is not set.
+	DefaultBlockBatchSize = 10
+	// DefaultIndexInterval and DefaultBalanceReconcileInterval are used if Options.IndexInterval and
+	// Options.BalanceReconcileInterval are not set.
+	DefaultIndexInterval            = 10 * time.Second
+	DefaultBalanceReconcileInterval = 5 * time.Minute
+	// MinBlockReceiptsTransactions and MinBlockReceiptsRatio are the number of watched transactions inside a block,
+	// And their ratio to all transactions of the block, From which all receipts of the block are fetched at once
+	// instead of one by one. Fetching all receipts of a busy block for a few watched transactions costs more than
+	// fetching them one by one, So both must be reached.
+	MinBlockReceiptsTransactions = 2
+	MinBlockReceiptsRatio        = 0.1
+
+	tracerName = "blockbook/pkg/bcparser"
 )
 
+// Options contains the configurable parameters of Parser.
+type Options struct {
+	// IndexInterval is the interval in which the parser checks the chain for new blocks.
+	IndexInterval time.Duration
+	// BalanceReconcileInterval is the interval in which the cached balances are re-fetched from the node to correct
+	// any drift, e.g. value transferred by internal transactions which is not visible to the indexer.
+	BalanceReconcileInterval time.Duration
+	// MaxLag is the number of blocks which the indexer can fall behind the chain head before being reported as not
+	// alive. Zero disables the check.
+	MaxLag uint64
+	// MaxStaleness is the duration which the indexer can go without indexing a block, Or confirming it has caught up
+	// with the chain head, before being reported as not alive. Zero disables the check.
+	MaxStaleness time.Duration
+	// Registerer is used to register the indexer metrics. Metrics are not exported if it's nil.
+	Registerer prometheus.Registerer
+	// BlockBatchSize is the maximum number of blocks fetched at once while catching up with the chain head or
+	// re-indexing.
+	BlockBatchSize int
+}
+
+// indexedTx is an entry of the transaction index.
+type indexedTx struct {
+	tx               *bcclient.Transaction
+	watchedAddresses []string
+	// refs is the number of address histories that contain the transaction. The entry is removed from the index
+	// when it drops to zero.
+	refs int
+	// successful is whether the value of the transaction has been transferred.
+	successful bool
+	// stats contains the stats which include the transaction by address, So they can be reverted when its block is
+	// re-indexed. Stats are dropped on unsubscribe, So they are only reverted if the address still has the same stats.
+	stats map[string]*addressStats
+}
+
 // Parser is an implementation of `bcparser.Parser` based on `bcclient.Client`.
 type Parser struct {
-	client              bcclient.Client
-	lastIndexedBlock    atomic.Uint64
-	subscribedAddresses *set.Set[string]
-	// mu is used to synchronize access to transactions.
+	client           bcclient.Client
+	lastIndexedBlock atomic.Uint64
+	chainHead        atomic.Uint64
+	state            atomic.Value
+	subscriptions    *subscriptions
+	// lastProgressAt is the unix nano time of the last time a block got indexed or the indexer caught up with the chain head.
+	lastProgressAt atomic.Int64
+	maxLag         uint64
+	maxStaleness   time.Duration
+	blockBatchSize uint64
+	metrics        *parserMetrics
+	tracer         trace.Tracer
+	// paused stops the indexer goroutine from looking for new blocks.
+	paused atomic.Bool
+	// indexInterval is the current index interval, The indexer goroutine resets its ticker when intervalSignal is sent.
+	indexInterval  atomic.Int64
+	intervalSignal chan struct{}
+	// reindexing is set while a re-index is queued or running, Ranges are sent to the re-indexer goroutine using
+	// reindexChan.
+	reindexing  atomic.Bool
+	reindexChan chan blockRange
+	lastReindex atomic.Pointer[bcparser.ReindexStatus]
+	// scanMu is held while the indexer is storing new blocks, So Restore does not interleave with them.
+	scanMu sync.Mutex
+	// restored is set once a snapshot has been restored, So the first scan continues from the restored last indexed
+	// block instead of the chain head.
+	restored atomic.Bool
+	// mu is used to synchronize access to transactions, txIndex, historyBlocks, blocks, balances and stats. It is also held while lastIndexedBlock is being
+	// updated so balances can be fetched consistently with the indexed blocks.
 	mu           sync.RWMutex
 	transactions map[string][]*bcclient.Transaction
-	logger       *zap.Logger
-	// ctxCancel is used by Stop() to stop the indexer goroutine.
+	// txIndex contains all transactions stored in transactions by their hash.
+	txIndex map[string]*indexedTx
+	// historyBlocks contains the addresses which their history contains transactions of a block by the block number,
+	// Alongside the number of those transactions. It is used to drop the transactions of re-indexed blocks.
+	historyBlocks map[uint64]map[string]int
+	blocks        map[uint64]*bcparser.BlockDetail
+	balances      map[string]bcparser.Balance
+	stats         map[string]*addressStats
+	// pendingBalances contains the addresses which their balance should be fetched by the balance tracker goroutine.
+	pendingBalances *set.Set[string]
+	balanceSignal   chan struct{}
+	logger          *zap.Logger
+	// ctxCancel is used by Stop() to stop the indexer and balance tracker goroutines.
 	ctxCancel context.CancelFunc
 	readyChan chan struct{}
 }
@@ -46,16 +149,65 @@ func (p *Parser) CurrentBlockNumber() uint64 {
 	return p.lastIndexedBlock.Load()
 }
 
-func (p *Parser) Subscribe(address string) bool {
-	return p.subscribedAddresses.Add(address)
+func (p *Parser) Subscribe(tenant string, subscription bcparser.Subscription) bool {
+	return p.SubscribeMany(tenant, []bcparser.Subscription{subscription})[0]
+}
+
+func (p *Parser) Unsubscribe(tenant string, address string) bool {
+	return p.UnsubscribeMany(tenant, []string{address})[0]
+}
+
+func (p *Parser) SubscribeMany(tenant string, subscriptions []bcparser.Subscription) []bool {
+	result, watched := p.subscriptions.addAll(tenant, subscriptions, time.Now())
+	if len(watched) > 0 {
+		p.requestBalances(watched)
+	}
+
+	return result
+}
+
+func (p *Parser) UnsubscribeMany(tenant string, addresses []string) []bool {
+	result, unwatched := p.subscriptions.removeAll(tenant, addresses)
+	if len(unwatched) == 0 {
+		return result
+	}
+
+	// balances and stats are only tracked for watched addresses.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, address := range unwatched {
+		delete(p.balances, address)
+		delete(p.stats, address)
+	}
+
+	return result
+}
+
+func (p *Parser) Subscription(tenant string, address string) (bcparser.Subscription, error) {
+	sub, ok := p.subscriptions.get(tenant, address)
+	if !ok {
+		return bcparser.Subscription{}, bcparser.ErrAddressNotSubscribed
+	}
+
+	return sub, nil
+}
+
+func (p *Parser) UpdateSubscription(tenant string, address string, update bcparser.SubscriptionUpdate) (bcparser.Subscription, error) {
+	sub, ok := p.subscriptions.update(tenant, address, update)
+	if !ok {
+		return bcparser.Subscription{}, bcparser.ErrAddressNotSubscribed
+	}
+
+	return sub, nil
 }
 
-func (p *Parser) Unsubscribe(address string) bool {
-	return p.subscribedAddresses.Remove(address)
+func (p *Parser) Subscriptions(tenant string, query bcparser.SubscriptionsQuery) bcparser.SubscriptionsPage {
+	return p.subscriptions.page(tenant, query)
 }
 
-func (p *Parser) Transactions(address string) []*bcclient.Transaction {
-	if !p.subscribedAddresses.Contains(address) {
+func (p *Parser) Transactions(tenant string, address string) []*bcclient.Transaction {
+	if !p.subscriptions.contains(tenant, address) {
 		return nil
 	}
 
@@ -69,75 +221,702 @@ func (p *Parser) Transactions(address string) []*bcclient.Transaction {
 		return make([]*bcclient.Transaction, 0)
 	}
 
-	return txs
+	// the history is modified in place when older transactions are re-indexed.
+	return slices.Clone(txs)
+}
+
+func (p *Parser) Balance(tenant string, address string) (bcparser.Balance, error) {
+	if !p.subscriptions.contains(tenant, address) {
+		return bcparser.Balance{}, bcparser.ErrAddressNotSubscribed
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	balance, ok := p.balances[address]
+	if !ok {
+		return bcparser.Balance{}, bcparser.ErrBalanceNotAvailable
+	}
+
+	// cached amounts are never modified in-place, so it is safe to share them with the caller.
+	return balance, nil
+}
+
+func (p *Parser) Stats(tenant string, address string) (bcparser.Stats, error) {
+	if !p.subscriptions.contains(tenant, address) {
+		return bcparser.Stats{}, bcparser.ErrAddressNotSubscribed
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	stats, ok := p.stats[address]
+	if !ok {
+		return newAddressStats().toStats(), nil
+	}
+
+	return stats.toStats(), nil
+}
+
+func (p *Parser) Transaction(ctx context.Context, tenant string, hash string) (bcparser.IndexedTransaction, error) {
+	p.mu.RLock()
+	entry, ok := p.txIndex[strings.ToLower(hash)]
+	p.mu.RUnlock()
+
+	var (
+		tx      bcclient.Transaction
+		indexed bool
+	)
+	if ok {
+		tx = *entry.tx
+		indexed = true
+	} else {
+		var err error
+		tx, err = p.client.Transaction(ctx, hash)
+		if err != nil {
+			if errors.Is(err, bcclient.ErrTransactionNotFound) {
+				return bcparser.IndexedTransaction{}, bcparser.ErrTransactionNotFound
+			}
+
+			return bcparser.IndexedTransaction{}, errors.Wrap(err, "could not get transaction from client")
+		}
+	}
+
+	// only reveal the addresses which the tenant has subscribed to.
+	watchedAddresses := make([]string, 0)
+	subscriptions := make([]bcparser.Subscription, 0)
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		if address == "" || slices.Contains(watchedAddresses, address) {
+			continue
+		}
+
+		if sub, ok := p.subscriptions.get(tenant, address); ok {
+			watchedAddresses = append(watchedAddresses, address)
+			subscriptions = append(subscriptions, sub)
+		}
+	}
+
+	return bcparser.IndexedTransaction{
+		Transaction:      tx,
+		WatchedAddresses: watchedAddresses,
+		Subscriptions:    subscriptions,
+		Indexed:          indexed,
+	}, nil
+}
+
+func (p *Parser) Block(ctx context.Context, tenant string, number uint64) (bcparser.BlockDetail, error) {
+	p.mu.RLock()
+	detail, ok := p.blocks[number]
+	p.mu.RUnlock()
+
+	if ok {
+		result := *detail
+		result.WatchedTransactions = filterWatchedTransactions(detail.WatchedTransactions, p.subscriptions.tenantWatchlist(tenant))
+
+		return result, nil
+	}
+
+	block, err := p.client.Block(ctx, number)
+	if err != nil {
+		if errors.Is(err, bcclient.ErrBlockNotFound) {
+			return bcparser.BlockDetail{}, bcparser.ErrBlockNotFound
+		}
+
+		return bcparser.BlockDetail{}, errors.Wrap(err, "could not get block from client")
+	}
+
+	result := newBlockDetail(block, filterWatchedTransactions(block.Transactions, p.subscriptions.tenantWatchlist(tenant)))
+	result.Indexed = false
+
+	return result, nil
+}
+
+func (p *Parser) Status() bcparser.Status {
+	chainHead := p.chainHead.Load()
+	lastIndexedBlock := p.lastIndexedBlock.Load()
+
+	var lag uint64
+	if chainHead > lastIndexedBlock {
+		lag = chainHead - lastIndexedBlock
+	}
+
+	return bcparser.Status{
+		ChainHead:        chainHead,
+		LastIndexedBlock: lastIndexedBlock,
+		Lag:              lag,
+		State:            p.getState(),
+	}
+}
+
+// CheckReady returns an error until the parser has done its initial scan. It can be used as a readiness check.
+func (p *Parser) CheckReady(_ context.Context) error {
+	select {
+	case <-p.readyChan:
+		return nil
+	default:
+		return bcparser.ErrParserNotReady
+	}
+}
+
+// CheckLive returns an error if the indexer is lagging too far behind the chain head, Or it has not made any progress
+// for too long. It can be used as a liveness check.
+func (p *Parser) CheckLive(_ context.Context) error {
+	// a paused indexer is not expected to make progress.
+	if p.paused.Load() {
+		return nil
+	}
+
+	if status := p.Status(); p.maxLag > 0 && status.Lag > p.maxLag {
+		return errors.Wrap(bcparser.ErrIndexerLagging, fmt.Sprintf("lag is %d blocks, threshold is %d", status.Lag, p.maxLag))
+	}
+
+	staleness := time.Since(time.Unix(0, p.lastProgressAt.Load()))
+	if p.maxStaleness > 0 && staleness > p.maxStaleness {
+		return errors.Wrap(bcparser.ErrIndexerStalled, fmt.Sprintf("last progress was %s ago, threshold is %s", staleness.Round(time.Second), p.maxStaleness))
+	}
+
+	return nil
+}
+
+func (p *Parser) getState() bcparser.IndexerState {
+	if p.paused.Load() {
+		return bcparser.IndexerStatePaused
+	}
+
+	state, ok := p.state.Load().(bcparser.IndexerState)
+	if !ok {
+		return bcparser.IndexerStateInitializing
+	}
+
+	return state
+}
+
+func (p *Parser) setState(state bcparser.IndexerState) {
+	p.state.Store(state)
 }
 
 // lookForNewBlocks checks if any new blocks are added to the chain since the last time and index all transactions inside new blocks if required.
 func (p *Parser) lookForNewBlocks(ctx context.Context, firstScan bool) error {
+	p.scanMu.Lock()
+	defer p.scanMu.Unlock()
+
 	currentBlockNum, err := p.client.CurrentBlockNumber(ctx)
 	if err != nil {
 		return errors.Wrap(err, "could not get current block number from client")
 	}
+	p.chainHead.Store(currentBlockNum)
 
 	lastIndexedBlock := p.lastIndexedBlock.Load()
 	blockToIndex := lastIndexedBlock + 1
-	// in case of the first scan, Start from the current block.
-	if firstScan {
+	// in case of the first scan, Start from the current block unless a snapshot has been restored.
+	if firstScan && !p.restored.Load() {
 		blockToIndex = currentBlockNum
 	}
 
+	if blockToIndex <= currentBlockNum {
+		p.setState(bcparser.IndexerStateSyncing)
+	}
+
 	// continue indexing until we reach the current block.
 	for blockToIndex <= currentBlockNum {
-		p.logger.Sugar().Infof("indexing block %d...", blockToIndex)
-
-		block, err := p.client.Block(ctx, blockToIndex)
+		lastBlock := min(blockToIndex+p.blockBatchSize-1, currentBlockNum)
+		blocks, err := p.client.Blocks(ctx, blockToIndex, lastBlock)
 		if err != nil {
-			return errors.Wrap(err, "could not get block from client")
+			return errors.Wrap(err, "could not get blocks from client")
 		}
 
-		p.processBlock(block)
-		p.logger.Sugar().Infof("block %d indexed", blockToIndex)
+		for _, block := range blocks {
+			err := p.indexBlock(ctx, block)
+			if err != nil {
+				return err
+			}
 
-		blockToIndex++
+			p.lastProgressAt.Store(time.Now().UnixNano())
+		}
+
+		blockToIndex = lastBlock + 1
 	}
+	p.setState(bcparser.IndexerStateIdle)
+	p.lastProgressAt.Store(time.Now().UnixNano())
 
 	return nil
 }
 
-// processBlock stores transactions inside a block in-memory if required.
-func (p *Parser) processBlock(block bcclient.Block) {
-	defer p.lastIndexedBlock.Store(block.Number)
+// indexBlock processes a block fetched from the client, Inside a span of its own.
+func (p *Parser) indexBlock(ctx context.Context, block bcclient.Block) (err error) {
+	ctx, span := p.tracer.Start(ctx, "bccparser.indexBlock", trace.WithAttributes(
+		attribute.Int64("block.number", int64(block.Number)),
+		attribute.Int("block.transactions", block.TransactionCount),
+	))
+	defer func() {
+		tracing.End(span, err)
+	}()
+
+	p.logger.Sugar().Infof("indexing block %d...", block.Number)
+
+	err = p.processBlock(ctx, block)
+	if err != nil {
+		return errors.Wrap(err, "could not process block")
+	}
+	p.logger.Sugar().Infof("block %d indexed", block.Number)
+
+	return nil
+}
 
+// processBlock stores transactions inside a block in-memory if required and updates the cached balances.
+func (p *Parser) processBlock(ctx context.Context, block bcclient.Block) error {
 	// first look for transactions involving subscribed addresses
-	watchlist := p.subscribedAddresses.ToSimpleMap() // clone the set as a map to avoid constantly locking-and-unlocking the set mutex in the for loop.
-	txToStore := make([]*bcclient.Transaction, 0)
-	for _, tx := range block.Transactions {
+	watchlist := p.subscriptions.watchlist() // clone the watchlist as a map to avoid constantly locking-and-unlocking the subscriptions mutex in the for loop.
+	txToStore := filterWatchedTransactions(block.Transactions, watchlist)
+
+	// receipts are required to know the paid fees and whether the value has been transferred or not.
+	receipts, err := p.fetchReceipts(ctx, block, txToStore)
+	if err != nil {
+		return err
+	}
+
+	// acquire write lock and start indexing transactions in-memory.
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, tx := range txToStore {
+		p.indexTransaction(tx, receipts[tx.Hash].Successful, watchlist)
+	}
+
+	p.storeBlock(block, txToStore)
+	p.updateBalances(block.Number, txToStore, receipts)
+	p.lastIndexedBlock.Store(block.Number)
+
+	p.metrics.indexedBlocks.Inc()
+	p.metrics.matchedTransactions.Add(float64(len(txToStore)))
+
+	return nil
+}
+
+// fetchReceipts returns the receipts of the given transactions of a block by their hash. Receipts are fetched one by
+// one, Unless the transactions reach MinBlockReceiptsTransactions and MinBlockReceiptsRatio of the block which all
+// receipts of the block are fetched at once.
+func (p *Parser) fetchReceipts(ctx context.Context, block bcclient.Block, txs []*bcclient.Transaction) (map[string]bcclient.Receipt, error) {
+	receipts := make(map[string]bcclient.Receipt, len(txs))
+
+	if !fetchesBlockReceipts(block, len(txs)) {
+		for _, tx := range txs {
+			receipt, err := p.client.Receipt(ctx, tx.Hash)
+			if err != nil {
+				return nil, errors.Wrap(err, "could not get transaction receipt from client")
+			}
+
+			receipts[tx.Hash] = receipt
+		}
+
+		return receipts, nil
+	}
+
+	blockReceipts, err := p.client.BlockReceipts(ctx, block.Number)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get block receipts from client")
+	}
+
+	byHash := make(map[string]bcclient.Receipt, len(blockReceipts))
+	for _, receipt := range blockReceipts {
+		byHash[strings.ToLower(receipt.TxHash)] = receipt
+	}
+
+	for _, tx := range txs {
+		receipt, ok := byHash[strings.ToLower(tx.Hash)]
+		if !ok {
+			return nil, errors.Wrap(bcclient.ErrReceiptNotFound, "could not find transaction receipt in block receipts")
+		}
+
+		receipts[tx.Hash] = receipt
+	}
+
+	return receipts, nil
+}
+
+// fetchesBlockReceipts returns whether all receipts of the block are fetched at once for txCount watched transactions.
+func fetchesBlockReceipts(block bcclient.Block, txCount int) bool {
+	if txCount < MinBlockReceiptsTransactions {
+		return false
+	}
+
+	return float64(txCount) >= MinBlockReceiptsRatio*float64(block.TransactionCount)
+}
+
+func newIndexedTx(tx *bcclient.Transaction, watchlist map[string]struct{}) *indexedTx {
+	watchedAddresses := make([]string, 0)
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		if _, ok := watchlist[address]; ok && !slices.Contains(watchedAddresses, address) {
+			watchedAddresses = append(watchedAddresses, address)
+		}
+	}
+
+	return &indexedTx{
+		tx:               tx,
+		watchedAddresses: watchedAddresses,
+		stats:            make(map[string]*addressStats),
+	}
+}
+
+// filterWatchedTransactions returns the transactions which are sent or received by an address in the watchlist.
+func filterWatchedTransactions(txs []*bcclient.Transaction, watchlist map[string]struct{}) []*bcclient.Transaction {
+	result := make([]*bcclient.Transaction, 0)
+	for _, tx := range txs {
 		_, senderSubscribed := watchlist[tx.FromAddress]
 		_, receiverSubscribed := watchlist[tx.ToAddress]
 		if !senderSubscribed && !receiverSubscribed {
 			continue
 		}
 
-		txToStore = append(txToStore, tx)
+		result = append(result, tx)
+	}
+
+	return result
+}
+
+func newBlockDetail(block bcclient.Block, watchedTxs []*bcclient.Transaction) bcparser.BlockDetail {
+	return bcparser.BlockDetail{
+		Number:              block.Number,
+		Hash:                block.Hash,
+		ParentHash:          block.ParentHash,
+		Timestamp:           block.Timestamp,
+		TransactionCount:    block.TransactionCount,
+		WatchedTransactions: watchedTxs,
+		Indexed:             true,
+	}
+}
+
+// storeBlock keeps the details of an indexed block and drops blocks which are too old. p.mu must be held by the caller.
+func (p *Parser) storeBlock(block bcclient.Block, watchedTxs []*bcclient.Transaction) {
+	detail := newBlockDetail(block, watchedTxs)
+	p.blocks[block.Number] = &detail
+
+	if block.Number >= MaxBlocksToKeep {
+		delete(p.blocks, block.Number-MaxBlocksToKeep)
+	}
+}
+
+// indexTransaction adds a watched transaction to the histories of its addresses and to the stats of the watched ones.
+// Addresses which their history is full of newer transactions are skipped, So a transaction which has already been
+// dropped from their history is not counted twice when an old block is re-indexed. p.mu must be held by the caller.
+func (p *Parser) indexTransaction(tx *bcclient.Transaction, successful bool, watchlist map[string]struct{}) {
+	hash := strings.ToLower(tx.Hash)
+	entry, ok := p.txIndex[hash]
+	if !ok {
+		entry = newIndexedTx(tx, watchlist)
+	}
+	entry.successful = successful
+
+	for _, address := range []string{tx.FromAddress, tx.ToAddress} {
+		if !p.keepsTransaction(address, tx) {
+			continue
+		}
+
+		p.txIndex[hash] = entry
+		p.storeTransaction(address, tx)
 	}
 
-	if len(txToStore) == 0 {
+	if _, ok := p.txIndex[hash]; !ok {
 		return
 	}
 
-	// acquire write lock and start indexing transactions in-memory.
-	p.mu.Lock()
-	defer p.mu.Unlock()
+	if _, ok := watchlist[tx.FromAddress]; ok && p.historyContains(tx.FromAddress, tx) {
+		p.addStats(entry, tx.FromAddress, false)
+	}
+	if _, ok := watchlist[tx.ToAddress]; ok && p.historyContains(tx.ToAddress, tx) {
+		p.addStats(entry, tx.ToAddress, true)
+	}
+}
 
-	for _, tx := range txToStore {
-		p.transactions[tx.FromAddress] = append(p.transactions[tx.FromAddress], tx)
-		if len(p.transactions[tx.FromAddress]) > MaxTxsToKeep {
-			p.transactions[tx.FromAddress] = p.transactions[tx.FromAddress][len(p.transactions[tx.FromAddress])-MaxTxsToKeep:]
+// keepsTransaction returns whether a transaction would be kept in the history of an address, A full history drops
+// transactions older than its oldest one right away. p.mu must be held by the caller.
+func (p *Parser) keepsTransaction(address string, tx *bcclient.Transaction) bool {
+	history := p.transactions[address]
+
+	return len(history) < MaxTxsToKeep || compareTransactions(history[0], tx) < 0
+}
+
+// historyContains returns whether a transaction is in the history of an address. p.mu must be held by the caller.
+func (p *Parser) historyContains(address string, tx *bcclient.Transaction) bool {
+	history := p.transactions[address]
+	position, found := slices.BinarySearchFunc(history, tx, compareTransactions)
+
+	return found && strings.EqualFold(history[position].Hash, tx.Hash)
+}
+
+// storeTransaction adds a transaction to the history of an address and drops the oldest transactions if the history
+// is too long. p.mu must be held by the caller.
+func (p *Parser) storeTransaction(address string, tx *bcclient.Transaction) {
+	// the history is kept in chain order, re-indexed blocks may contain transactions older than the stored ones.
+	history := p.transactions[address]
+	position := len(history)
+	for position > 0 && compareTransactions(history[position-1], tx) > 0 {
+		position--
+	}
+	p.transactions[address] = slices.Insert(history, position, tx)
+	p.trackHistoryBlock(address, tx.BlockNumber, 1)
+	if entry, ok := p.txIndex[strings.ToLower(tx.Hash)]; ok {
+		entry.refs++
+	}
+
+	if len(p.transactions[address]) <= MaxTxsToKeep {
+		return
+	}
+
+	dropped := p.transactions[address][:len(p.transactions[address])-MaxTxsToKeep]
+	for _, droppedTx := range dropped {
+		p.trackHistoryBlock(address, droppedTx.BlockNumber, -1)
+		hash := strings.ToLower(droppedTx.Hash)
+		entry, ok := p.txIndex[hash]
+		if !ok {
+			continue
+		}
+
+		entry.refs--
+		if entry.refs <= 0 {
+			delete(p.txIndex, hash)
+		}
+	}
+
+	p.transactions[address] = p.transactions[address][len(p.transactions[address])-MaxTxsToKeep:]
+}
+
+// dropBlockTransactions removes the transactions of a block from the histories, The index and the stats. It returns
+// the addresses which their history has changed. p.mu must be held by the caller.
+func (p *Parser) dropBlockTransactions(number uint64) []string {
+	compareBlockNumber := func(tx *bcclient.Transaction, number uint64) int {
+		return cmp.Compare(tx.BlockNumber, number)
+	}
+
+	addresses := make([]string, 0, len(p.historyBlocks[number]))
+	dropped := make(map[string]*indexedTx)
+	for address := range p.historyBlocks[number] {
+		history := p.transactions[address]
+		from, _ := slices.BinarySearchFunc(history, number, compareBlockNumber)
+		to, _ := slices.BinarySearchFunc(history, number+1, compareBlockNumber)
+		if from == to {
+			continue
 		}
 
-		p.transactions[tx.ToAddress] = append(p.transactions[tx.ToAddress], tx)
-		if len(p.transactions[tx.ToAddress]) > MaxTxsToKeep {
-			p.transactions[tx.ToAddress] = p.transactions[tx.ToAddress][len(p.transactions[tx.ToAddress])-MaxTxsToKeep:]
+		for _, tx := range history[from:to] {
+			hash := strings.ToLower(tx.Hash)
+			if entry, ok := p.txIndex[hash]; ok {
+				dropped[hash] = entry
+			}
+		}
+
+		addresses = append(addresses, address)
+		if history = slices.Delete(history, from, to); len(history) == 0 {
+			delete(p.transactions, address)
+		} else {
+			p.transactions[address] = history
+		}
+	}
+
+	delete(p.historyBlocks, number)
+
+	for hash, entry := range dropped {
+		p.revertStats(entry)
+		delete(p.txIndex, hash)
+	}
+
+	return addresses
+}
+
+// trackHistoryBlock adds delta to the number of transactions of a block in the history of an address. p.mu must be
+// held by the caller.
+func (p *Parser) trackHistoryBlock(address string, blockNumber uint64, delta int) {
+	addresses, ok := p.historyBlocks[blockNumber]
+	if !ok {
+		addresses = make(map[string]int)
+		p.historyBlocks[blockNumber] = addresses
+	}
+
+	addresses[address] += delta
+	if addresses[address] <= 0 {
+		delete(addresses, address)
+	}
+	if len(addresses) == 0 {
+		delete(p.historyBlocks, blockNumber)
+	}
+}
+
+// compareTransactions orders transactions by their position in the chain.
+func compareTransactions(a, b *bcclient.Transaction) int {
+	if c := cmp.Compare(a.BlockNumber, b.BlockNumber); c != 0 {
+		return c
+	}
+
+	return cmp.Compare(a.Position, b.Position)
+}
+
+// updateBalances applies the changes caused by the given transactions of a block to the cached balances. p.mu must be
+// held by the caller.
+func (p *Parser) updateBalances(blockNumber uint64, txs []*bcclient.Transaction, receipts map[string]bcclient.Receipt) {
+	deltas := make(map[string]*big.Int)
+	addDelta := func(address string, amount *big.Int) {
+		if _, ok := deltas[address]; !ok {
+			deltas[address] = new(big.Int)
+		}
+		deltas[address].Add(deltas[address], amount)
+	}
+
+	for _, tx := range txs {
+		receipt := receipts[tx.Hash]
+		addDelta(tx.FromAddress, new(big.Int).Neg(receipt.Fee()))
+		if receipt.Successful {
+			addDelta(tx.FromAddress, new(big.Int).Neg(tx.Amount))
+			addDelta(tx.ToAddress, tx.Amount)
+		}
+	}
+
+	for address, balance := range p.balances {
+		// balance has been fetched after this block, so it already includes its transactions.
+		if balance.BlockNumber >= blockNumber {
+			continue
+		}
+
+		amount := balance.Amount
+		if delta, ok := deltas[address]; ok {
+			amount = new(big.Int).Add(amount, delta)
+		}
+
+		p.balances[address] = bcparser.Balance{
+			Amount:      amount,
+			BlockNumber: blockNumber,
+		}
+	}
+}
+
+// addStats adds an indexed transaction to the stats of one of its addresses. p.mu must be held by the caller.
+func (p *Parser) addStats(entry *indexedTx, address string, incoming bool) {
+	stats, ok := p.stats[address]
+	if !ok {
+		stats = newAddressStats()
+		p.stats[address] = stats
+	}
+
+	stats.add(entry.tx.BlockNumber, entry.tx, incoming, entry.successful)
+	entry.stats[address] = stats
+}
+
+// revertStats removes an indexed transaction from the stats which include it. p.mu must be held by the caller.
+func (p *Parser) revertStats(entry *indexedTx) {
+	for address, stats := range entry.stats {
+		if p.stats[address] != stats {
+			continue
+		}
+
+		if address == entry.tx.FromAddress {
+			stats.remove(entry.tx, false, entry.successful)
+		}
+		if address == entry.tx.ToAddress {
+			stats.remove(entry.tx, true, entry.successful)
+		}
+	}
+	clear(entry.stats)
+}
+
+// requestBalances asks the balance tracker goroutine to fetch the balance of the given addresses.
+func (p *Parser) requestBalances(addresses []string) {
+	p.pendingBalances.AddAll(addresses...)
+
+	select {
+	case p.balanceSignal <- struct{}{}:
+	default: // balance tracker has already been signaled.
+	}
+}
+
+// fetchBalance gets the balance of an address from the client at the last indexed block and stores it in the cache.
+func (p *Parser) fetchBalance(ctx context.Context, address string) error {
+	for range MaxBalanceFetchAttempts {
+		blockNumber := p.lastIndexedBlock.Load()
+		amount, err := p.client.Balance(ctx, address, blockNumber)
+		if err != nil {
+			return errors.Wrap(err, "could not get balance from client")
+		}
+
+		stored := func() bool {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+
+			// a new block has been indexed while we were fetching the balance, so the fetched balance is already outdated.
+			if p.lastIndexedBlock.Load() != blockNumber {
+				return false
+			}
+
+			if !p.subscriptions.watched(address) {
+				return true
+			}
+
+			if cached, ok := p.balances[address]; ok && cached.BlockNumber == blockNumber && cached.Amount.Cmp(amount) != 0 {
+				p.logger.Warn("cached balance drifted from the node, reconciling",
+					zap.String("address", address),
+					zap.Uint64("blockNumber", blockNumber),
+					zap.Stringer("cached", cached.Amount),
+					zap.Stringer("actual", amount),
+				)
+			}
+
+			p.balances[address] = bcparser.Balance{
+				Amount:      amount,
+				BlockNumber: blockNumber,
+			}
+
+			return true
+		}()
+		if stored {
+			return nil
+		}
+		p.metrics.retries.WithLabelValues(retryOperationBalance).Inc()
+	}
+
+	return errors.New("chain moved on while fetching balance")
+}
+
+// fetchBalances fetches the balance of all given addresses. Failed addresses are retried in the next reconciliation.
+func (p *Parser) fetchBalances(ctx context.Context, addresses map[string]struct{}) {
+	for address := range addresses {
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := p.fetchBalance(ctx, address)
+		if err != nil {
+			p.logger.Error("could not fetch balance", zap.String("address", address), zap.Error(err))
+		}
+	}
+}
+
+// startBalanceTracking launches the balance tracker goroutine which fetches the balance of newly subscribed addresses
+// and periodically reconciles the cached balances with the node.
+func (p *Parser) startBalanceTracking(ctx context.Context, reconcileInterval time.Duration) {
+	// balances are fetched at the last indexed block, so we have to wait for the initial scan.
+	select {
+	case <-ctx.Done():
+		return
+	case <-p.readyChan:
+	}
+
+	ticker := time.NewTicker(reconcileInterval)
+	defer ticker.Stop()
+
+	for {
+		pending := p.pendingBalances.ToSimpleMap()
+		for address := range pending {
+			p.pendingBalances.Remove(address)
+		}
+		p.fetchBalances(ctx, pending)
+
+		select {
+		case <-ctx.Done():
+			return
+
+		case <-p.balanceSignal:
+
+		case <-ticker.C:
+			p.logger.Debug("reconciling balances...")
+			p.fetchBalances(ctx, p.subscriptions.watchlist())
 		}
 	}
 }
@@ -157,6 +936,7 @@ func (p *Parser) startIndexing(ctx context.Context, interval time.Duration) {
 
 	err := p.lookForNewBlocks(ctx, true)
 	if err != nil {
+		p.setState(bcparser.IndexerStateFailing)
 		p.logger.Error("could not do initial block scan", zap.Error(err))
 	} else {
 		markReady()
@@ -169,7 +949,14 @@ func (p *Parser) startIndexing(ctx context.Context, interval time.Duration) {
 
 			return
 
+		case <-p.intervalSignal:
+			ticker.Reset(time.Duration(p.indexInterval.Load()))
+
 		case <-ticker.C:
+			if p.paused.Load() {
+				continue
+			}
+
 			b := &backoff.ExponentialBackOff{
 				InitialInterval:     BackoffInitialFactor,
 				RandomizationFactor: backoff.DefaultRandomizationFactor,
@@ -181,16 +968,19 @@ func (p *Parser) startIndexing(ctx context.Context, interval time.Duration) {
 			}
 			b.Reset()
 
-			_ = backoff.Retry(func() error {
+			_ = backoff.RetryNotify(func() error {
 				err := p.lookForNewBlocks(ctx, firstScan)
 				if err != nil {
+					p.setState(bcparser.IndexerStateFailing)
 					p.logger.Error("could not scan blocks", zap.Error(err))
 				} else {
 					markReady()
 				}
 
 				return err
-			}, b)
+			}, b, func(error, time.Duration) {
+				p.metrics.retries.WithLabelValues(retryOperationScan).Inc()
+			})
 		}
 	}
 }
@@ -204,18 +994,47 @@ func (p *Parser) Stop() {
 	p.ctxCancel()
 }
 
-func New(logger *zap.Logger, client bcclient.Client, indexInterval time.Duration) *Parser {
+func New(logger *zap.Logger, client bcclient.Client, options Options) *Parser {
 	ctx, cancel := context.WithCancel(context.Background())
 	p := &Parser{
-		client:              client,
-		logger:              logging.AddComponent(logger, "block-parser"),
-		ctxCancel:           cancel,
-		subscribedAddresses: set.New[string](),
-		transactions:        make(map[string][]*bcclient.Transaction),
-		readyChan:           make(chan struct{}),
+		client:          client,
+		logger:          logging.AddComponent(logger, "block-parser"),
+		ctxCancel:       cancel,
+		subscriptions:   newSubscriptions(),
+		transactions:    make(map[string][]*bcclient.Transaction),
+		txIndex:         make(map[string]*indexedTx),
+		historyBlocks:   make(map[uint64]map[string]int),
+		blocks:          make(map[uint64]*bcparser.BlockDetail),
+		balances:        make(map[string]bcparser.Balance),
+		stats:           make(map[string]*addressStats),
+		pendingBalances: set.New[string](),
+		balanceSignal:   make(chan struct{}, 1),
+		readyChan:       make(chan struct{}),
+		maxLag:          options.MaxLag,
+		maxStaleness:    options.MaxStaleness,
+		blockBatchSize:  uint64(options.BlockBatchSize),
+		tracer:          tracing.Tracer(tracerName),
+		intervalSignal:  make(chan struct{}, 1),
+		reindexChan:     make(chan blockRange, 1),
+	}
+
+	if options.BlockBatchSize <= 0 {
+		p.blockBatchSize = DefaultBlockBatchSize
+	}
+	if options.IndexInterval <= 0 {
+		options.IndexInterval = DefaultIndexInterval
+	}
+	if options.BalanceReconcileInterval <= 0 {
+		options.BalanceReconcileInterval = DefaultBalanceReconcileInterval
 	}
+	p.metrics = newParserMetrics(options.Registerer, p)
+	p.setState(bcparser.IndexerStateInitializing)
+	p.lastProgressAt.Store(time.Now().UnixNano())
+	p.indexInterval.Store(int64(options.IndexInterval))
 
-	go p.startIndexing(ctx, indexInterval)
+	go p.startIndexing(ctx, options.IndexInterval)
+	go p.startReindexing(ctx)
+	go p.startBalanceTracking(ctx, options.BalanceReconcileInterval)
 
 	return p
 }
@@ -0,0 +1,493 @@
+package bccparser
+
+import (
+	"blockbook/pkg/bcclient"
+	fakeclient "blockbook/pkg/bcclient/fake"
+	tracingclient "blockbook/pkg/bcclient/tracing"
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/errors"
+	"bytes"
+	"context"
+	"io"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.uber.org/zap"
+)
+
+const (
+	testIndexInterval = 10 * time.Millisecond
+	testWaitTimeout   = 5 * time.Second
+	testTenant        = "tenant"
+
+	address1 = "0x627306090abaB3A6e1400e9345bC60c78a8BEf57"
+	address2 = "0xf17f52151EbEF6C7334FAD080c5704D77216b732"
+	address3 = "0xC5fdf4076b8F3A5357c5E395ab970B5B54098Fef"
+)
+
+func setupParser(t *testing.T) (*Parser, *fakeclient.Client) {
+	t.Helper()
+
+	client := fakeclient.New()
+	client.AppendBlocks(5)
+
+	parser := New(zap.NewNop(), client, Options{
+		IndexInterval:            testIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+	})
+	t.Cleanup(parser.Stop)
+
+	select {
+	case <-parser.Ready():
+	case <-time.After(testWaitTimeout):
+		t.Fatal("parser did not become ready")
+	}
+
+	return parser, client
+}
+
+func eth(amount int64) *big.Int {
+	return new(big.Int).Mul(big.NewInt(amount), big.NewInt(1000000000000000000))
+}
+
+func waitForBlock(t *testing.T, parser *Parser, number uint64) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return parser.CurrentBlockNumber() >= number
+	}, testWaitTimeout, testIndexInterval)
+}
+
+func TestIndexesWatchedTransactions(t *testing.T) {
+	parser, client := setupParser(t)
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	block := client.AppendBlock(
+		fakeclient.NewTransaction(address1, address2, eth(1)),
+		fakeclient.NewTransaction(address2, address3, eth(2)),
+	)
+	waitForBlock(t, parser, block.Number)
+
+	txs := parser.Transactions(testTenant, address1)
+	require.Len(t, txs, 1)
+	assert.Equal(t, block.Transactions[0].Hash, txs[0].Hash)
+	assert.Equal(t, block.Number, txs[0].BlockNumber)
+
+	assert.Nil(t, parser.Transactions(testTenant, address3))
+	assert.Nil(t, parser.Transactions("another-tenant", address1))
+
+	stats, err := parser.Stats(testTenant, address1)
+	require.NoError(t, err)
+	assert.Equal(t, 0, eth(1).Cmp(stats.TotalSent))
+	assert.Equal(t, uint64(1), stats.TxCountOut)
+
+	detail, err := parser.Block(context.Background(), testTenant, block.Number)
+	require.NoError(t, err)
+	assert.True(t, detail.Indexed)
+	assert.Equal(t, block.Hash, detail.Hash)
+	assert.Len(t, detail.WatchedTransactions, 1)
+}
+
+func TestBalanceFollowsTransactions(t *testing.T) {
+	parser, client := setupParser(t)
+	client.SetInitialBalance(address1, eth(10))
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	require.Eventually(t, func() bool {
+		_, err := parser.Balance(testTenant, address1)
+
+		return err == nil
+	}, testWaitTimeout, testIndexInterval)
+
+	block := client.AppendBlock(
+		fakeclient.NewTransaction(address1, address2, eth(1)),
+		fakeclient.NewTransaction(address3, address1, eth(3)),
+	)
+	waitForBlock(t, parser, block.Number)
+
+	expected, err := client.Balance(context.Background(), address1, block.Number)
+	require.NoError(t, err)
+
+	balance, err := parser.Balance(testTenant, address1)
+	require.NoError(t, err)
+	assert.Equal(t, block.Number, balance.BlockNumber)
+	assert.Equal(t, 0, expected.Cmp(balance.Amount), "expected %s, got %s", expected, balance.Amount)
+}
+
+func TestRecoversFromClientErrors(t *testing.T) {
+	parser, client := setupParser(t)
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	errRPC := errors.New("rpc is down")
+	client.InjectError(fakeclient.MethodBlocks, errRPC)
+	client.InjectError(fakeclient.MethodReceipt, errRPC)
+
+	block := client.AppendBlock(fakeclient.NewTransaction(address1, address2, eth(1)))
+	waitForBlock(t, parser, block.Number)
+
+	assert.Len(t, parser.Transactions(testTenant, address1), 1)
+	assert.GreaterOrEqual(t, client.Calls(fakeclient.MethodBlocks), 3)
+	assert.NoError(t, parser.CheckLive(context.Background()))
+}
+
+func TestFetchesBlockReceiptsForBusyBlocks(t *testing.T) {
+	parser, client := setupParser(t)
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	block := client.AppendBlock(
+		fakeclient.NewTransaction(address1, address2, eth(1)),
+		fakeclient.NewTransaction(address3, address1, eth(2)),
+	)
+	waitForBlock(t, parser, block.Number)
+
+	assert.Len(t, parser.Transactions(testTenant, address1), 2)
+	assert.Equal(t, 1, client.Calls(fakeclient.MethodBlockReceipts))
+	assert.Equal(t, 0, client.Calls(fakeclient.MethodReceipt))
+}
+
+func TestNewUsesDefaultOptions(t *testing.T) {
+	client := fakeclient.New()
+	client.AppendBlocks(2)
+	client.SetInitialBalance(address1, eth(10))
+
+	parser := New(zap.NewNop(), client, Options{BlockBatchSize: -1})
+	t.Cleanup(parser.Stop)
+	assert.Equal(t, uint64(DefaultBlockBatchSize), parser.blockBatchSize)
+	assert.Equal(t, DefaultIndexInterval, parser.IndexerInfo().IndexInterval)
+
+	// balances are fetched by the balance tracker, So it is running with the default reconcile interval.
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+	require.Eventually(t, func() bool {
+		_, err := parser.Balance(testTenant, address1)
+
+		return err == nil
+	}, testWaitTimeout, testIndexInterval)
+}
+
+func TestFetchesReceiptsOneByOneForFewWatchedTransactions(t *testing.T) {
+	parser, client := setupParser(t)
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	txs := []*bcclient.Transaction{
+		fakeclient.NewTransaction(address1, address2, eth(1)),
+		fakeclient.NewTransaction(address3, address1, eth(2)),
+	}
+	for range 30 {
+		txs = append(txs, fakeclient.NewTransaction(address2, address3, eth(1)))
+	}
+	block := client.AppendBlock(txs...)
+	waitForBlock(t, parser, block.Number)
+
+	assert.Len(t, parser.Transactions(testTenant, address1), 2)
+	assert.Equal(t, 0, client.Calls(fakeclient.MethodBlockReceipts))
+	assert.Equal(t, 2, client.Calls(fakeclient.MethodReceipt))
+}
+
+func TestPauseAndResume(t *testing.T) {
+	parser, client := setupParser(t)
+	lastIndexedBlock := parser.CurrentBlockNumber()
+
+	parser.Pause()
+	assert.Equal(t, bcparser.IndexerStatePaused, parser.Status().State)
+
+	head := client.AppendBlocks(3)
+	time.Sleep(10 * testIndexInterval)
+	assert.Equal(t, lastIndexedBlock, parser.CurrentBlockNumber())
+
+	parser.Resume()
+	waitForBlock(t, parser, head)
+}
+
+// waitForReindex waits for the last re-index to finish and returns its status.
+func waitForReindex(t *testing.T, parser *Parser) bcparser.ReindexStatus {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		status := parser.IndexerInfo().LastReindex
+
+		return status != nil && !status.Running
+	}, testWaitTimeout, testIndexInterval)
+
+	return *parser.IndexerInfo().LastReindex
+}
+
+func TestReindexBackfillsHistory(t *testing.T) {
+	parser, client := setupParser(t)
+
+	oldBlock := client.AppendBlock(fakeclient.NewTransaction(address2, address1, eth(1)))
+	waitForBlock(t, parser, oldBlock.Number)
+
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+	newBlock := client.AppendBlock(fakeclient.NewTransaction(address1, address2, eth(2)))
+	waitForBlock(t, parser, newBlock.Number)
+	before := parser.Transactions(testTenant, address1)
+	require.Len(t, before, 1)
+
+	assert.ErrorIs(t, parser.Reindex(newBlock.Number, newBlock.Number+1), bcparser.ErrInvalidBlockRange)
+	require.NoError(t, parser.Reindex(oldBlock.Number, newBlock.Number))
+	assert.Empty(t, waitForReindex(t, parser).Error)
+
+	txs := parser.Transactions(testTenant, address1)
+	require.Len(t, txs, 2)
+	assert.Equal(t, []string{oldBlock.Transactions[0].Hash, newBlock.Transactions[0].Hash}, []string{txs[0].Hash, txs[1].Hash})
+	// histories are modified in place, So previously returned slices must not change.
+	assert.Equal(t, newBlock.Transactions[0].Hash, before[0].Hash)
+
+	// re-indexed blocks are counted once, Including the ones indexed before the subscription.
+	stats, err := parser.Stats(testTenant, address1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.TxCountIn)
+	assert.Equal(t, uint64(1), stats.TxCountOut)
+	assert.Equal(t, 0, eth(1).Cmp(stats.TotalReceived))
+	assert.Equal(t, oldBlock.Number, stats.FirstSeenBlock)
+	assert.Equal(t, newBlock.Number, stats.LastSeenBlock)
+}
+
+func TestReindexReplacesStaleTransactions(t *testing.T) {
+	parser, client := setupParser(t)
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	stale := client.AppendBlock(fakeclient.NewTransaction(address1, address2, eth(1)))
+	waitForBlock(t, parser, stale.Number)
+	require.Eventually(t, func() bool {
+		balance, err := parser.Balance(testTenant, address1)
+
+		return err == nil && balance.BlockNumber == stale.Number
+	}, testWaitTimeout, testIndexInterval)
+
+	// the block is replaced without the indexer noticing, e.g. the provider has returned a wrong block.
+	parser.Pause()
+	client.Reorg(1)
+	fresh := client.AppendBlock(fakeclient.NewTransaction(address3, address1, eth(2)))
+	require.Equal(t, stale.Number, fresh.Number)
+
+	require.NoError(t, parser.Reindex(fresh.Number, fresh.Number))
+	assert.Empty(t, waitForReindex(t, parser).Error)
+
+	txs := parser.Transactions(testTenant, address1)
+	require.Len(t, txs, 1)
+	assert.Equal(t, fresh.Transactions[0].Hash, txs[0].Hash)
+
+	_, err := parser.Transaction(context.Background(), testTenant, stale.Transactions[0].Hash)
+	assert.ErrorIs(t, err, bcparser.ErrTransactionNotFound)
+
+	stats, err := parser.Stats(testTenant, address1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(0), stats.TxCountOut)
+	assert.Equal(t, 0, stats.TotalSent.Sign())
+	assert.Equal(t, uint64(1), stats.TxCountIn)
+	assert.Equal(t, 0, eth(2).Cmp(stats.TotalReceived))
+	require.Len(t, stats.TopCounterparties, 1)
+	assert.Equal(t, address3, stats.TopCounterparties[0].Address)
+
+	expected, err := client.Balance(context.Background(), address1, fresh.Number)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		balance, err := parser.Balance(testTenant, address1)
+
+		return err == nil && expected.Cmp(balance.Amount) == 0
+	}, testWaitTimeout, testIndexInterval)
+}
+
+func TestReindexSkipsTransactionsOlderThanFullHistory(t *testing.T) {
+	parser, client := setupParser(t)
+
+	oldBlock := client.AppendBlock(fakeclient.NewTransaction(address1, address2, eth(1)))
+	waitForBlock(t, parser, oldBlock.Number)
+
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+	txs := make([]*bcclient.Transaction, 0, MaxTxsToKeep)
+	for range MaxTxsToKeep {
+		txs = append(txs, fakeclient.NewTransaction(address1, address3, eth(1)))
+	}
+	newBlock := client.AppendBlock(txs...)
+	waitForBlock(t, parser, newBlock.Number)
+
+	require.NoError(t, parser.Reindex(oldBlock.Number, oldBlock.Number))
+	assert.Empty(t, waitForReindex(t, parser).Error)
+
+	history := parser.Transactions(testTenant, address1)
+	require.Len(t, history, MaxTxsToKeep)
+	assert.Equal(t, newBlock.Number, history[0].BlockNumber)
+
+	stats, err := parser.Stats(testTenant, address1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(MaxTxsToKeep), stats.TxCountOut)
+
+	// the indexer is still running.
+	head := client.AppendBlocks(1)
+	waitForBlock(t, parser, head)
+}
+
+func TestLivenessFailsWhenLagging(t *testing.T) {
+	client := fakeclient.New()
+	parser := New(zap.NewNop(), client, Options{
+		IndexInterval:            testIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+		MaxLag:                   2,
+	})
+	t.Cleanup(parser.Stop)
+	<-parser.Ready()
+
+	require.NoError(t, parser.CheckReady(context.Background()))
+
+	client.SetDelay(fakeclient.MethodBlocks, time.Hour)
+	client.AppendBlocks(5)
+
+	require.Eventually(t, func() bool {
+		return errors.Is(parser.CheckLive(context.Background()), bcparser.ErrIndexerLagging)
+	}, testWaitTimeout, testIndexInterval)
+}
+
+func TestScanBlocks(t *testing.T) {
+	client := fakeclient.New()
+	client.AppendBlocks(2)
+	first := client.AppendBlock(fakeclient.NewTransaction(address1, address2, eth(1)))
+	client.AppendBlock(fakeclient.NewTransaction(address2, address3, eth(1)))
+	last := client.AppendBlock(fakeclient.NewTransaction(address3, address1, eth(2)))
+
+	scanned := make([]uint64, 0)
+	matched := make([]string, 0)
+	err := ScanBlocks(context.Background(), client, []string{address1}, first.Number, last.Number, 2, func(block bcclient.Block, txs []*bcclient.Transaction) error {
+		scanned = append(scanned, block.Number)
+		for _, tx := range txs {
+			matched = append(matched, tx.Hash)
+		}
+
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{first.Number, first.Number + 1, last.Number}, scanned)
+	assert.Equal(t, []string{first.Transactions[0].Hash, last.Transactions[0].Hash}, matched)
+	assert.Equal(t, 2, client.Calls(fakeclient.MethodBlocks))
+
+	err = ScanBlocks(context.Background(), client, []string{address1}, last.Number, first.Number, 2, nil)
+	assert.ErrorIs(t, err, bcparser.ErrInvalidBlockRange)
+}
+
+func TestSnapshotAndRestore(t *testing.T) {
+	parser, client := setupParser(t)
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1, Label: "hot wallet", Tags: []string{"exchange"}})
+	parser.Subscribe("another-tenant", bcparser.Subscription{Address: address2})
+
+	block := client.AppendBlock(fakeclient.NewTransaction(address1, address2, eth(1)))
+	waitForBlock(t, parser, block.Number)
+
+	var snapshot bytes.Buffer
+	require.NoError(t, parser.Snapshot(&snapshot))
+	parser.Stop()
+
+	// mined while the parser was down.
+	missed := client.AppendBlock(fakeclient.NewTransaction(address3, address1, eth(2)))
+
+	restored := New(zap.NewNop(), client, Options{
+		IndexInterval:            testIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+	})
+	t.Cleanup(restored.Stop)
+	require.NoError(t, restored.Restore(&snapshot))
+	waitForBlock(t, restored, missed.Number)
+
+	sub, err := restored.Subscription(testTenant, address1)
+	require.NoError(t, err)
+	assert.Equal(t, "hot wallet", sub.Label)
+	assert.Equal(t, []string{"exchange"}, sub.Tags)
+
+	txs := restored.Transactions(testTenant, address1)
+	require.Len(t, txs, 2)
+	assert.Equal(t, []string{block.Transactions[0].Hash, missed.Transactions[0].Hash}, []string{txs[0].Hash, txs[1].Hash})
+	assert.Len(t, restored.Transactions("another-tenant", address2), 1)
+
+	stats, err := restored.Stats(testTenant, address1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.TxCountOut)
+	assert.Equal(t, uint64(1), stats.TxCountIn)
+
+	indexed, err := restored.Transaction(context.Background(), "another-tenant", block.Transactions[0].Hash)
+	require.NoError(t, err)
+	assert.True(t, indexed.Indexed)
+	assert.Equal(t, []string{address2}, indexed.WatchedAddresses)
+
+	// restored transactions are not counted twice when their block is re-indexed.
+	require.NoError(t, restored.Reindex(block.Number, block.Number))
+	assert.Empty(t, waitForReindex(t, restored).Error)
+	stats, err = restored.Stats(testTenant, address1)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.TxCountOut)
+	assert.Equal(t, 0, eth(1).Cmp(stats.TotalSent))
+}
+
+func TestRestoreRejectsInvalidSnapshots(t *testing.T) {
+	parser, _ := setupParser(t)
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	var snapshot bytes.Buffer
+	require.NoError(t, parser.Snapshot(&snapshot))
+
+	corrupt := func(fn func(data []byte)) io.Reader {
+		data := bytes.Clone(snapshot.Bytes())
+		fn(data)
+
+		return bytes.NewReader(data)
+	}
+
+	assert.ErrorIs(t, parser.Restore(strings.NewReader("not a snapshot")), ErrInvalidSnapshot)
+	assert.ErrorIs(t, parser.Restore(corrupt(func(data []byte) { data[len(data)-1]++ })), ErrSnapshotChecksumMismatch)
+	assert.ErrorIs(t, parser.Restore(corrupt(func(data []byte) { data[len(snapshotMagic)+1]++ })), ErrUnsupportedSnapshotVersion)
+	assert.ErrorIs(t, parser.Restore(bytes.NewReader(snapshot.Bytes()[:snapshot.Len()-1])), ErrInvalidSnapshot)
+
+	// a rejected snapshot leaves the state untouched.
+	assert.NotNil(t, parser.Transactions(testTenant, address1))
+}
+
+func TestTracesIndexedBlocks(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() {
+		otel.SetTracerProvider(provider)
+	})
+
+	client := fakeclient.New()
+	parser := New(zap.NewNop(), tracingclient.New(client), Options{
+		IndexInterval:            testIndexInterval,
+		BalanceReconcileInterval: time.Minute,
+	})
+	t.Cleanup(parser.Stop)
+	<-parser.Ready()
+	parser.Subscribe(testTenant, bcparser.Subscription{Address: address1})
+
+	first := client.AppendBlock(fakeclient.NewTransaction(address1, address2, eth(1)))
+	second := client.AppendBlock()
+	waitForBlock(t, parser, second.Number)
+
+	blockSpans := make(map[int64]sdktrace.ReadOnlySpan)
+	receiptSpans := make([]sdktrace.ReadOnlySpan, 0)
+	for _, span := range recorder.Ended() {
+		switch span.Name() {
+		case "bccparser.indexBlock":
+			for _, attr := range span.Attributes() {
+				if attr.Key == "block.number" {
+					assert.NotContains(t, blockSpans, attr.Value.AsInt64(), "block is traced twice")
+					blockSpans[attr.Value.AsInt64()] = span
+				}
+			}
+		case "bcclient.Receipt":
+			receiptSpans = append(receiptSpans, span)
+		}
+	}
+
+	require.Contains(t, blockSpans, int64(first.Number))
+	require.Contains(t, blockSpans, int64(second.Number))
+
+	// client calls made while indexing a block are children of its span.
+	require.Len(t, receiptSpans, 1)
+	assert.Equal(t, blockSpans[int64(first.Number)].SpanContext().SpanID(), receiptSpans[0].Parent().SpanID())
+}
@@ -0,0 +1,128 @@
+package bccparser
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcclient/cassette"
+	ethclient "blockbook/pkg/bcclient/eth"
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/errors"
+	"context"
+	"flag"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// recordRPC is the address of a mainnet node which the mainnet cassette is recorded from, e.g.
+// `go test ./pkg/bcparser/bcc -run TestReplayMainnetCassette -record-rpc https://ethereum-rpc.publicnode.com`.
+var recordRPC = flag.String("record-rpc", "", "record the mainnet cassette from the given rpc address")
+
+const (
+	// the mainnet cassette contains the blocks of the first transaction of Ethereum and the empty block before it.
+	mainnetFromBlock = 46146
+	mainnetToBlock   = 46147
+	mainnetSender    = "0xA1E4380A3B1f749673E270229993eE55F35663b4"
+	mainnetReceiver  = "0x5DF9B87991262F6BA471F09758CDE1c0FC1De734"
+	mainnetTxHash    = "0x5c504ed432cb51138bcf09aa5e8a410dd4a1e204ef84bfed1be16dfba1b22060"
+)
+
+var mainnetCassettePath = filepath.Join("testdata", "mainnet.json")
+
+// pinnedHeadClient reports a fixed chain head, So the recorded cassette does not contain the blocks of the actual head.
+type pinnedHeadClient struct {
+	bcclient.Client
+	head uint64
+}
+
+func (c pinnedHeadClient) CurrentBlockNumber(_ context.Context) (uint64, error) {
+	return c.head, nil
+}
+
+// backfillMainnetBlocks runs a parser which watches the sender and the receiver of the mainnet transaction over client,
+// And backfills the blocks of the mainnet cassette.
+func backfillMainnetBlocks(t *testing.T, client bcclient.Client) *Parser {
+	t.Helper()
+
+	parser := New(zap.NewNop(), client, Options{
+		IndexInterval:            time.Hour,
+		BalanceReconcileInterval: time.Hour,
+	})
+	t.Cleanup(parser.Stop)
+
+	select {
+	case <-parser.Ready():
+	case <-time.After(testWaitTimeout):
+		t.Fatal("parser did not become ready")
+	}
+
+	parser.SubscribeMany(testTenant, []bcparser.Subscription{
+		{Address: mainnetSender},
+		{Address: mainnetReceiver},
+	})
+	require.NoError(t, parser.Backfill(context.Background(), mainnetFromBlock, mainnetToBlock))
+
+	return parser
+}
+
+// recordMainnetCassette records the responses which backfilling the mainnet blocks needs from the node at rpcAddress.
+func recordMainnetCassette(t *testing.T, rpcAddress string) {
+	t.Helper()
+
+	client, err := ethclient.New(rpcAddress, ethclient.Options{})
+	require.NoError(t, err)
+
+	recorder := cassette.NewRecorder(pinnedHeadClient{Client: client, head: mainnetToBlock})
+	parser := backfillMainnetBlocks(t, recorder)
+
+	// balances are fetched in the background, So they are waited for to be recorded too.
+	require.Eventually(t, func() bool {
+		for _, address := range []string{mainnetSender, mainnetReceiver} {
+			if _, err := parser.Balance(testTenant, address); err != nil {
+				return false
+			}
+		}
+
+		return true
+	}, 30*time.Second, 100*time.Millisecond)
+	parser.Stop()
+
+	require.NoError(t, os.MkdirAll(filepath.Dir(mainnetCassettePath), 0o755))
+	require.NoError(t, recorder.SaveFile(mainnetCassettePath))
+}
+
+func TestReplayMainnetCassette(t *testing.T) {
+	if *recordRPC != "" {
+		recordMainnetCassette(t, *recordRPC)
+	}
+
+	recorded, err := cassette.LoadFile(mainnetCassettePath)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Skipf("%s has not been recorded, Run the test with -record-rpc to record it", mainnetCassettePath)
+	}
+	require.NoError(t, err)
+
+	parser := backfillMainnetBlocks(t, cassette.NewPlayer(recorded))
+
+	createdAt := time.Date(2015, time.August, 7, 3, 30, 33, 0, time.UTC)
+	for _, address := range []string{mainnetSender, mainnetReceiver} {
+		txs := parser.Transactions(testTenant, address)
+		require.Len(t, txs, 1, address)
+		assert.Equal(t, mainnetTxHash, txs[0].Hash)
+		assert.Equal(t, mainnetSender, txs[0].FromAddress)
+		assert.Equal(t, mainnetReceiver, txs[0].ToAddress)
+		assert.Equal(t, big.NewInt(31337), txs[0].Amount)
+		assert.Equal(t, uint64(mainnetToBlock), txs[0].BlockNumber)
+		assert.True(t, createdAt.Equal(txs[0].CreatedAt), txs[0].CreatedAt)
+	}
+
+	stats, err := parser.Stats(testTenant, mainnetReceiver)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), stats.TxCountIn)
+	assert.Equal(t, big.NewInt(31337), stats.TotalReceived)
+}
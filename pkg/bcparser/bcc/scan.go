@@ -0,0 +1,48 @@
+package bccparser
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/errors"
+	"context"
+)
+
+// ScanBlocks fetches the blocks from `from` to `to` in batches of batchSize blocks, And calls fn with the transactions
+// of each block which are sent or received by one of the given addresses, Using the same matching as the indexer.
+// Nothing is indexed, So it can be used for offline jobs without a running parser. fn is called for every block even if
+// none of its transactions match.
+func ScanBlocks(ctx context.Context, client bcclient.Client, addresses []string, from uint64, to uint64, batchSize int, fn func(block bcclient.Block, txs []*bcclient.Transaction) error) error {
+	if from > to {
+		return bcparser.ErrInvalidBlockRange
+	}
+	if batchSize <= 0 {
+		batchSize = DefaultBlockBatchSize
+	}
+
+	watchlist := make(map[string]struct{}, len(addresses))
+	for _, address := range addresses {
+		watchlist[address] = struct{}{}
+	}
+
+	for start := from; start <= to; {
+		end := min(start+uint64(batchSize)-1, to)
+		blocks, err := client.Blocks(ctx, start, end)
+		if err != nil {
+			return errors.Wrap(err, "could not get blocks from client")
+		}
+
+		for _, block := range blocks {
+			if err := fn(block, filterWatchedTransactions(block.Transactions, watchlist)); err != nil {
+				return err
+			}
+		}
+
+		// avoid overflowing when the range ends at the last possible block number.
+		if end == to {
+			break
+		}
+		start = end + 1
+	}
+
+	return nil
+}
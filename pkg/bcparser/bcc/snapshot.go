@@ -0,0 +1,429 @@
+package bccparser
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/errors"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	// SnapshotVersion is the version of the snapshot format written by Snapshot. Restore rejects snapshots of other
+	// versions.
+	SnapshotVersion uint16 = 1
+
+	// snapshotMagic is written at the beginning of every snapshot, So other files are rejected early.
+	snapshotMagic = "BBSNAP"
+	// maxSnapshotSize is the maximum size of a compressed snapshot payload which Restore accepts.
+	maxSnapshotSize = 1 << 30
+)
+
+var (
+	ErrInvalidSnapshot            = errors.New("file is not a valid snapshot")
+	ErrUnsupportedSnapshotVersion = errors.New("snapshot version is not supported")
+	ErrSnapshotChecksumMismatch   = errors.New("snapshot checksum does not match its content")
+)
+
+// snapshotHeader precedes the payload of a snapshot. The payload is followed by its SHA-256 checksum.
+type snapshotHeader struct {
+	Magic       [len(snapshotMagic)]byte
+	Version     uint16
+	PayloadSize uint64
+}
+
+// snapshotPayload is the gzip compressed JSON content of a snapshot. It has its own types instead of reusing the API
+// ones, So changing the API responses does not break the stored snapshots.
+type snapshotPayload struct {
+	LastIndexedBlock uint64 `json:"lastIndexedBlock"`
+	// Subscriptions are grouped by tenant.
+	Subscriptions map[string][]snapshotSubscription `json:"subscriptions"`
+	// Transactions contains every transaction of the histories once, Histories refer to them by hash.
+	Transactions []snapshotTransaction    `json:"transactions"`
+	Histories    map[string][]string      `json:"histories"`
+	Stats        map[string]snapshotStats `json:"stats"`
+}
+
+type snapshotSubscription struct {
+	Address   string    `json:"address"`
+	Label     string    `json:"label"`
+	Tags      []string  `json:"tags"`
+	OwnerID   string    `json:"ownerId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type snapshotTransaction struct {
+	Hash        string    `json:"hash"`
+	FromAddress string    `json:"fromAddress"`
+	ToAddress   string    `json:"toAddress"`
+	Amount      *big.Int  `json:"amount"`
+	BlockNumber uint64    `json:"blockNumber"`
+	Position    uint      `json:"position"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Successful  bool      `json:"successful"`
+	// StatsAddresses contains the addresses which their stats include the transaction.
+	StatsAddresses []string `json:"statsAddresses"`
+}
+
+type snapshotStats struct {
+	TotalReceived  *big.Int               `json:"totalReceived"`
+	TotalSent      *big.Int               `json:"totalSent"`
+	TxCountIn      uint64                 `json:"txCountIn"`
+	TxCountOut     uint64                 `json:"txCountOut"`
+	FirstSeenBlock uint64                 `json:"firstSeenBlock"`
+	LastSeenBlock  uint64                 `json:"lastSeenBlock"`
+	Counterparties []snapshotCounterparty `json:"counterparties"`
+}
+
+type snapshotCounterparty struct {
+	Address string   `json:"address"`
+	TxCount uint64   `json:"txCount"`
+	Volume  *big.Int `json:"volume"`
+}
+
+// Snapshot writes the subscriptions, transaction histories, stats and the last indexed block of the parser to w. Block
+// details and balances are not included since they are fetched from the client again after a restore.
+func (p *Parser) Snapshot(w io.Writer) error {
+	payload := p.snapshotPayload()
+
+	var compressed bytes.Buffer
+	gz := gzip.NewWriter(&compressed)
+	if err := json.NewEncoder(gz).Encode(payload); err != nil {
+		return errors.Wrap(err, "could not encode snapshot")
+	}
+	if err := gz.Close(); err != nil {
+		return errors.Wrap(err, "could not compress snapshot")
+	}
+
+	header := snapshotHeader{
+		Version:     SnapshotVersion,
+		PayloadSize: uint64(compressed.Len()),
+	}
+	copy(header.Magic[:], snapshotMagic)
+	checksum := sha256.Sum256(compressed.Bytes())
+
+	if err := binary.Write(w, binary.BigEndian, header); err != nil {
+		return errors.Wrap(err, "could not write snapshot header")
+	}
+	if _, err := w.Write(compressed.Bytes()); err != nil {
+		return errors.Wrap(err, "could not write snapshot")
+	}
+	if _, err := w.Write(checksum[:]); err != nil {
+		return errors.Wrap(err, "could not write snapshot checksum")
+	}
+
+	return nil
+}
+
+// Restore replaces the state of the parser with a snapshot written by Snapshot. It can be called while the parser is
+// running, The indexer continues from the block after the restored last indexed block, So blocks mined since the
+// snapshot are indexed too. Balances of the restored subscriptions are fetched again.
+func (p *Parser) Restore(r io.Reader) error {
+	payload, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+
+	// validate the histories before touching the current state.
+	txs := make(map[string]snapshotTransaction, len(payload.Transactions))
+	for _, tx := range payload.Transactions {
+		txs[strings.ToLower(tx.Hash)] = tx
+	}
+	for _, hashes := range payload.Histories {
+		for _, hash := range hashes {
+			if _, ok := txs[strings.ToLower(hash)]; !ok {
+				return errors.Wrap(ErrInvalidSnapshot, "history refers to a missing transaction")
+			}
+		}
+	}
+
+	// wait for the block being indexed, So it is not stored on top of the restored state.
+	p.scanMu.Lock()
+	defer p.scanMu.Unlock()
+
+	restored := newSubscriptions()
+	for tenant, subs := range payload.Subscriptions {
+		for _, sub := range subs {
+			restored.addAll(tenant, []bcparser.Subscription{sub.toSubscription()}, sub.CreatedAt)
+		}
+	}
+	watchlist := restored.watchlist()
+
+	p.mu.Lock()
+	p.subscriptions.replace(restored)
+	p.stats = make(map[string]*addressStats, len(payload.Stats))
+	for address, stats := range payload.Stats {
+		p.stats[address] = stats.toAddressStats()
+	}
+	p.transactions = make(map[string][]*bcclient.Transaction, len(payload.Histories))
+	p.txIndex = make(map[string]*indexedTx, len(txs))
+	p.historyBlocks = make(map[uint64]map[string]int)
+	for address, hashes := range payload.Histories {
+		history := make([]*bcclient.Transaction, 0, len(hashes))
+		for _, hash := range hashes {
+			hash = strings.ToLower(hash)
+			entry, ok := p.txIndex[hash]
+			if !ok {
+				entry = p.restoreIndexedTx(txs[hash], watchlist)
+				p.txIndex[hash] = entry
+			}
+
+			entry.refs++
+			history = append(history, entry.tx)
+			p.trackHistoryBlock(address, entry.tx.BlockNumber, 1)
+		}
+		p.transactions[address] = history
+	}
+	p.blocks = make(map[uint64]*bcparser.BlockDetail)
+	p.balances = make(map[string]bcparser.Balance)
+	p.lastIndexedBlock.Store(payload.LastIndexedBlock)
+	p.restored.Store(true)
+	p.mu.Unlock()
+
+	addresses := make([]string, 0, len(watchlist))
+	for address := range watchlist {
+		addresses = append(addresses, address)
+	}
+	p.requestBalances(addresses)
+
+	p.logger.Sugar().Infof("restored %d transactions of %d addresses up to block %d", len(txs), len(watchlist), payload.LastIndexedBlock)
+
+	return nil
+}
+
+// SnapshotInfo summarizes the state of a snapshot without restoring it.
+type SnapshotInfo struct {
+	LastIndexedBlock uint64
+	// Subscriptions is the number of subscriptions of each tenant.
+	Subscriptions map[string]int
+}
+
+// ReadSnapshotInfo reads a snapshot written by Snapshot and returns its summary, So the state of a parser can be
+// inspected offline.
+func ReadSnapshotInfo(r io.Reader) (SnapshotInfo, error) {
+	payload, err := readSnapshot(r)
+	if err != nil {
+		return SnapshotInfo{}, err
+	}
+
+	info := SnapshotInfo{
+		LastIndexedBlock: payload.LastIndexedBlock,
+		Subscriptions:    make(map[string]int, len(payload.Subscriptions)),
+	}
+	for tenant, subs := range payload.Subscriptions {
+		info.Subscriptions[tenant] = len(subs)
+	}
+
+	return info, nil
+}
+
+// readSnapshot verifies the header and checksum of a snapshot and decodes its payload.
+func readSnapshot(r io.Reader) (snapshotPayload, error) {
+	var header snapshotHeader
+	if err := binary.Read(r, binary.BigEndian, &header); err != nil {
+		return snapshotPayload{}, errors.Wrap(ErrInvalidSnapshot, fmt.Sprintf("could not read snapshot header: %v", err))
+	}
+	if string(header.Magic[:]) != snapshotMagic {
+		return snapshotPayload{}, ErrInvalidSnapshot
+	}
+	if header.Version != SnapshotVersion {
+		return snapshotPayload{}, ErrUnsupportedSnapshotVersion
+	}
+	if header.PayloadSize > maxSnapshotSize {
+		return snapshotPayload{}, errors.Wrap(ErrInvalidSnapshot, "snapshot is too large")
+	}
+
+	compressed := make([]byte, header.PayloadSize)
+	if _, err := io.ReadFull(r, compressed); err != nil {
+		return snapshotPayload{}, errors.Wrap(ErrInvalidSnapshot, fmt.Sprintf("could not read snapshot: %v", err))
+	}
+	var checksum [sha256.Size]byte
+	if _, err := io.ReadFull(r, checksum[:]); err != nil {
+		return snapshotPayload{}, errors.Wrap(ErrInvalidSnapshot, fmt.Sprintf("could not read snapshot checksum: %v", err))
+	}
+	if sha256.Sum256(compressed) != checksum {
+		return snapshotPayload{}, ErrSnapshotChecksumMismatch
+	}
+
+	gz, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return snapshotPayload{}, errors.Wrap(ErrInvalidSnapshot, fmt.Sprintf("could not decompress snapshot: %v", err))
+	}
+	defer gz.Close()
+
+	var payload snapshotPayload
+	if err := json.NewDecoder(gz).Decode(&payload); err != nil {
+		return snapshotPayload{}, errors.Wrap(ErrInvalidSnapshot, fmt.Sprintf("could not decode snapshot: %v", err))
+	}
+
+	return payload, nil
+}
+
+// snapshotPayload collects the state of the parser which is written into snapshots.
+func (p *Parser) snapshotPayload() snapshotPayload {
+	payload := snapshotPayload{
+		Subscriptions: make(map[string][]snapshotSubscription),
+	}
+	for tenant, subs := range p.subscriptions.all() {
+		records := make([]snapshotSubscription, 0, len(subs))
+		for _, sub := range subs {
+			records = append(records, newSnapshotSubscription(sub))
+		}
+		payload.Subscriptions[tenant] = records
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	payload.LastIndexedBlock = p.lastIndexedBlock.Load()
+	payload.Transactions = make([]snapshotTransaction, 0, len(p.txIndex))
+	for _, entry := range p.txIndex {
+		payload.Transactions = append(payload.Transactions, p.newSnapshotTransaction(entry))
+	}
+	payload.Histories = make(map[string][]string, len(p.transactions))
+	for address, history := range p.transactions {
+		hashes := make([]string, 0, len(history))
+		for _, tx := range history {
+			hashes = append(hashes, tx.Hash)
+		}
+		payload.Histories[address] = hashes
+	}
+	payload.Stats = make(map[string]snapshotStats, len(p.stats))
+	for address, stats := range p.stats {
+		payload.Stats[address] = newSnapshotStats(stats)
+	}
+
+	return payload
+}
+
+func newSnapshotSubscription(sub bcparser.Subscription) snapshotSubscription {
+	return snapshotSubscription{
+		Address:   sub.Address,
+		Label:     sub.Label,
+		Tags:      sub.Tags,
+		OwnerID:   sub.OwnerID,
+		CreatedAt: sub.CreatedAt,
+	}
+}
+
+func (s snapshotSubscription) toSubscription() bcparser.Subscription {
+	return bcparser.Subscription{
+		Address:   s.Address,
+		Label:     s.Label,
+		Tags:      s.Tags,
+		OwnerID:   s.OwnerID,
+		CreatedAt: s.CreatedAt,
+	}
+}
+
+// newSnapshotTransaction converts an entry of the transaction index. p.mu must be held by the caller.
+func (p *Parser) newSnapshotTransaction(entry *indexedTx) snapshotTransaction {
+	statsAddresses := make([]string, 0, len(entry.stats))
+	for address, stats := range entry.stats {
+		if p.stats[address] == stats {
+			statsAddresses = append(statsAddresses, address)
+		}
+	}
+	slices.Sort(statsAddresses)
+
+	return snapshotTransaction{
+		Hash:           entry.tx.Hash,
+		FromAddress:    entry.tx.FromAddress,
+		ToAddress:      entry.tx.ToAddress,
+		Amount:         entry.tx.Amount,
+		BlockNumber:    entry.tx.BlockNumber,
+		Position:       entry.tx.Position,
+		CreatedAt:      entry.tx.CreatedAt,
+		Successful:     entry.successful,
+		StatsAddresses: statsAddresses,
+	}
+}
+
+// restoreIndexedTx converts a snapshot transaction into an entry of the transaction index, Its stats refer to the
+// restored ones. p.mu must be held by the caller.
+func (p *Parser) restoreIndexedTx(s snapshotTransaction, watchlist map[string]struct{}) *indexedTx {
+	entry := newIndexedTx(s.toTransaction(), watchlist)
+	entry.successful = s.Successful
+	for _, address := range s.StatsAddresses {
+		if stats, ok := p.stats[address]; ok {
+			entry.stats[address] = stats
+		}
+	}
+
+	return entry
+}
+
+func (s snapshotTransaction) toTransaction() *bcclient.Transaction {
+	amount := s.Amount
+	if amount == nil {
+		amount = new(big.Int)
+	}
+
+	return &bcclient.Transaction{
+		Hash:        s.Hash,
+		FromAddress: s.FromAddress,
+		ToAddress:   s.ToAddress,
+		Amount:      amount,
+		BlockNumber: s.BlockNumber,
+		Position:    s.Position,
+		CreatedAt:   s.CreatedAt,
+	}
+}
+
+func newSnapshotStats(s *addressStats) snapshotStats {
+	counterparties := make([]snapshotCounterparty, 0, len(s.counterparties))
+	for _, counterparty := range s.counterparties {
+		counterparties = append(counterparties, snapshotCounterparty{
+			Address: counterparty.Address,
+			TxCount: counterparty.TxCount,
+			Volume:  new(big.Int).Set(counterparty.Volume),
+		})
+	}
+
+	return snapshotStats{
+		TotalReceived:  new(big.Int).Set(s.totalReceived),
+		TotalSent:      new(big.Int).Set(s.totalSent),
+		TxCountIn:      s.txCountIn,
+		TxCountOut:     s.txCountOut,
+		FirstSeenBlock: s.firstSeenBlock,
+		LastSeenBlock:  s.lastSeenBlock,
+		Counterparties: counterparties,
+	}
+}
+
+func (s snapshotStats) toAddressStats() *addressStats {
+	stats := newAddressStats()
+	if s.TotalReceived != nil {
+		stats.totalReceived.Set(s.TotalReceived)
+	}
+	if s.TotalSent != nil {
+		stats.totalSent.Set(s.TotalSent)
+	}
+	stats.txCountIn = s.TxCountIn
+	stats.txCountOut = s.TxCountOut
+	stats.firstSeenBlock = s.FirstSeenBlock
+	stats.lastSeenBlock = s.LastSeenBlock
+	for _, counterparty := range s.Counterparties {
+		volume := new(big.Int)
+		if counterparty.Volume != nil {
+			volume.Set(counterparty.Volume)
+		}
+
+		stats.counterparties[counterparty.Address] = &bcparser.Counterparty{
+			Address: counterparty.Address,
+			TxCount: counterparty.TxCount,
+			Volume:  volume,
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,169 @@
+package bccparser
+
+import (
+	"blockbook/pkg/bcclient"
+	"blockbook/pkg/bcparser"
+	"math/big"
+	"slices"
+	"strings"
+)
+
+const (
+	// MaxCounterpartiesToTrack limits the number of counterparties tracked per address. When the limit is reached, the
+	// least active counterparty is evicted in favor of the new one.
+	MaxCounterpartiesToTrack = 1000
+	// TopCounterpartiesNum is the number of counterparties returned in the stats.
+	TopCounterpartiesNum = 10
+)
+
+// addressStats holds the running aggregates of a subscribed address.
+type addressStats struct {
+	totalReceived  *big.Int
+	totalSent      *big.Int
+	txCountIn      uint64
+	txCountOut     uint64
+	firstSeenBlock uint64
+	lastSeenBlock  uint64
+	counterparties map[string]*bcparser.Counterparty
+}
+
+func newAddressStats() *addressStats {
+	return &addressStats{
+		totalReceived:  new(big.Int),
+		totalSent:      new(big.Int),
+		counterparties: make(map[string]*bcparser.Counterparty),
+	}
+}
+
+// add updates the aggregates with a transaction sent or received by the address.
+func (s *addressStats) add(blockNumber uint64, tx *bcclient.Transaction, incoming bool, successful bool) {
+	// re-indexed blocks may be older than the ones seen so far.
+	if s.firstSeenBlock == 0 || blockNumber < s.firstSeenBlock {
+		s.firstSeenBlock = blockNumber
+	}
+	s.lastSeenBlock = max(s.lastSeenBlock, blockNumber)
+
+	counterpartyAddress := tx.ToAddress
+	if incoming {
+		counterpartyAddress = tx.FromAddress
+		s.txCountIn++
+	} else {
+		s.txCountOut++
+	}
+
+	amount := new(big.Int)
+	if successful {
+		amount = tx.Amount
+	}
+
+	if incoming {
+		s.totalReceived.Add(s.totalReceived, amount)
+	} else {
+		s.totalSent.Add(s.totalSent, amount)
+	}
+
+	counterparty, ok := s.counterparties[counterpartyAddress]
+	if !ok {
+		if len(s.counterparties) >= MaxCounterpartiesToTrack {
+			s.evictLeastActiveCounterparty()
+		}
+
+		counterparty = &bcparser.Counterparty{
+			Address: counterpartyAddress,
+			Volume:  new(big.Int),
+		}
+		s.counterparties[counterpartyAddress] = counterparty
+	}
+	counterparty.TxCount++
+	counterparty.Volume.Add(counterparty.Volume, amount)
+}
+
+// remove reverts add for a transaction which is being re-indexed. First and last seen blocks are kept since they are
+// not tracked per transaction.
+func (s *addressStats) remove(tx *bcclient.Transaction, incoming bool, successful bool) {
+	counterpartyAddress := tx.ToAddress
+	if incoming {
+		counterpartyAddress = tx.FromAddress
+		s.txCountIn--
+	} else {
+		s.txCountOut--
+	}
+
+	amount := new(big.Int)
+	if successful {
+		amount = tx.Amount
+	}
+
+	if incoming {
+		s.totalReceived.Sub(s.totalReceived, amount)
+	} else {
+		s.totalSent.Sub(s.totalSent, amount)
+	}
+
+	// the counterparty may have been evicted since.
+	counterparty, ok := s.counterparties[counterpartyAddress]
+	if !ok {
+		return
+	}
+
+	if counterparty.TxCount <= 1 {
+		delete(s.counterparties, counterpartyAddress)
+
+		return
+	}
+	counterparty.TxCount--
+	counterparty.Volume.Sub(counterparty.Volume, amount)
+}
+
+func (s *addressStats) evictLeastActiveCounterparty() {
+	var leastActive *bcparser.Counterparty
+	for _, counterparty := range s.counterparties {
+		if leastActive == nil || compareCounterparties(*counterparty, *leastActive) > 0 {
+			leastActive = counterparty
+		}
+	}
+
+	if leastActive != nil {
+		delete(s.counterparties, leastActive.Address)
+	}
+}
+
+// toStats returns a deep copy of the aggregates which is safe to be shared with callers.
+func (s *addressStats) toStats() bcparser.Stats {
+	counterparties := make([]bcparser.Counterparty, 0, len(s.counterparties))
+	for _, counterparty := range s.counterparties {
+		counterparties = append(counterparties, bcparser.Counterparty{
+			Address: counterparty.Address,
+			TxCount: counterparty.TxCount,
+			Volume:  new(big.Int).Set(counterparty.Volume),
+		})
+	}
+	slices.SortFunc(counterparties, compareCounterparties)
+
+	return bcparser.Stats{
+		TotalReceived:     new(big.Int).Set(s.totalReceived),
+		TotalSent:         new(big.Int).Set(s.totalSent),
+		TxCountIn:         s.txCountIn,
+		TxCountOut:        s.txCountOut,
+		FirstSeenBlock:    s.firstSeenBlock,
+		LastSeenBlock:     s.lastSeenBlock,
+		TopCounterparties: counterparties[:min(len(counterparties), TopCounterpartiesNum)],
+	}
+}
+
+// compareCounterparties orders counterparties by their activity, The most active ones come first.
+func compareCounterparties(a, b bcparser.Counterparty) int {
+	if a.TxCount != b.TxCount {
+		if a.TxCount > b.TxCount {
+			return -1
+		}
+
+		return 1
+	}
+
+	if c := b.Volume.Cmp(a.Volume); c != 0 {
+		return c
+	}
+
+	return strings.Compare(a.Address, b.Address)
+}
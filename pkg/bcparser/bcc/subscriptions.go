@@ -0,0 +1,344 @@
+package bccparser
+
+import (
+	"blockbook/pkg/bcparser"
+	"blockbook/pkg/set"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSubscriptionsPageSize is used when the limit of a subscriptions query is not set.
+const DefaultSubscriptionsPageSize = 100
+
+// tenantSubscriptions contains the subscription records of a single tenant alongside indexes of them by address and
+// tag.
+type tenantSubscriptions struct {
+	records map[string]*bcparser.Subscription
+	// addresses contains the addresses subscribed by the tenant. It is used for ordered iteration.
+	addresses *set.Set[string]
+	// byTag maps each tag to the addresses having it.
+	byTag map[string]*set.Set[string]
+}
+
+func newTenantSubscriptions() *tenantSubscriptions {
+	return &tenantSubscriptions{
+		records:   make(map[string]*bcparser.Subscription),
+		addresses: set.New[string](),
+		byTag:     make(map[string]*set.Set[string]),
+	}
+}
+
+// subscriptions keeps the subscription records of all tenants. An address is watched as long as at least one tenant
+// has subscribed to it. It is thread-safe.
+type subscriptions struct {
+	mu      sync.RWMutex
+	tenants map[string]*tenantSubscriptions
+	// watchers is the number of tenants subscribed to each address.
+	watchers map[string]int
+	// addresses contains the addresses subscribed by any tenant. It is used for lock-free lookups.
+	addresses *set.Set[string]
+}
+
+func newSubscriptions() *subscriptions {
+	return &subscriptions{
+		tenants:   make(map[string]*tenantSubscriptions),
+		watchers:  make(map[string]int),
+		addresses: set.New[string](),
+	}
+}
+
+// addAll stores the given subscriptions of a tenant while acquiring the lock only once. The returned slice indicates
+// whether each subscription was new for the tenant or not, and watched contains the addresses which were not watched
+// by any tenant before.
+func (s *subscriptions) addAll(tenant string, subs []bcparser.Subscription, createdAt time.Time) ([]bool, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts, ok := s.tenants[tenant]
+	if !ok {
+		ts = newTenantSubscriptions()
+		s.tenants[tenant] = ts
+	}
+
+	result := make([]bool, len(subs))
+	watched := make([]string, 0, len(subs))
+	for i, sub := range subs {
+		if _, ok := ts.records[sub.Address]; ok {
+			continue
+		}
+
+		sub.Tags = normalizeTags(sub.Tags)
+		sub.CreatedAt = createdAt
+		ts.records[sub.Address] = &sub
+		ts.addresses.Add(sub.Address)
+		ts.indexTags(sub.Address, sub.Tags)
+		result[i] = true
+
+		s.watchers[sub.Address]++
+		if s.watchers[sub.Address] == 1 {
+			s.addresses.Add(sub.Address)
+			watched = append(watched, sub.Address)
+		}
+	}
+
+	return result, watched
+}
+
+// removeAll deletes the subscriptions of a tenant for the given addresses while acquiring the lock only once. The
+// returned slice indicates whether each address was removed or not, and unwatched contains the addresses which are no
+// longer watched by any tenant.
+func (s *subscriptions) removeAll(tenant string, addresses []string) ([]bool, []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]bool, len(addresses))
+	unwatched := make([]string, 0)
+
+	ts, ok := s.tenants[tenant]
+	if !ok {
+		return result, unwatched
+	}
+
+	for i, address := range addresses {
+		sub, ok := ts.records[address]
+		if !ok {
+			continue
+		}
+
+		ts.unindexTags(address, sub.Tags)
+		ts.addresses.Remove(address)
+		delete(ts.records, address)
+		result[i] = true
+
+		s.watchers[address]--
+		if s.watchers[address] <= 0 {
+			delete(s.watchers, address)
+			s.addresses.Remove(address)
+			unwatched = append(unwatched, address)
+		}
+	}
+
+	if len(ts.records) == 0 {
+		delete(s.tenants, tenant)
+	}
+
+	return result, unwatched
+}
+
+// watched checks whether any tenant has subscribed to an address or not.
+func (s *subscriptions) watched(address string) bool {
+	return s.addresses.Contains(address)
+}
+
+// contains checks whether a tenant has subscribed to an address or not.
+func (s *subscriptions) contains(tenant, address string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ts, ok := s.tenants[tenant]
+	if !ok {
+		return false
+	}
+
+	_, ok = ts.records[address]
+
+	return ok
+}
+
+func (s *subscriptions) get(tenant, address string) (bcparser.Subscription, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ts, ok := s.tenants[tenant]
+	if !ok {
+		return bcparser.Subscription{}, false
+	}
+
+	sub, ok := ts.records[address]
+	if !ok {
+		return bcparser.Subscription{}, false
+	}
+
+	return cloneSubscription(sub), true
+}
+
+func (s *subscriptions) update(tenant, address string, update bcparser.SubscriptionUpdate) (bcparser.Subscription, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ts, ok := s.tenants[tenant]
+	if !ok {
+		return bcparser.Subscription{}, false
+	}
+
+	sub, ok := ts.records[address]
+	if !ok {
+		return bcparser.Subscription{}, false
+	}
+
+	if update.Label != nil {
+		sub.Label = *update.Label
+	}
+	if update.OwnerID != nil {
+		sub.OwnerID = *update.OwnerID
+	}
+	if update.Tags != nil {
+		ts.unindexTags(address, sub.Tags)
+		sub.Tags = normalizeTags(*update.Tags)
+		ts.indexTags(address, sub.Tags)
+	}
+
+	return cloneSubscription(sub), true
+}
+
+// page returns a page of the subscriptions of a tenant matching the query, ordered by address.
+func (s *subscriptions) page(tenant string, query bcparser.SubscriptionsQuery) bcparser.SubscriptionsPage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	empty := bcparser.SubscriptionsPage{Subscriptions: make([]bcparser.Subscription, 0)}
+
+	ts, ok := s.tenants[tenant]
+	if !ok {
+		return empty
+	}
+
+	index := ts.addresses
+	if query.Tag != "" {
+		index, ok = ts.byTag[query.Tag]
+		if !ok {
+			return empty
+		}
+	}
+
+	var filter func(address string) bool
+	if query.Prefix != "" {
+		prefix := strings.ToLower(query.Prefix)
+		filter = func(address string) bool {
+			return strings.HasPrefix(strings.ToLower(address), prefix)
+		}
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = DefaultSubscriptionsPageSize
+	}
+
+	// fetch one more address to know whether there is a next page or not.
+	addresses, total := set.Page(index, query.After, limit+1, filter)
+	hasMore := len(addresses) > limit
+	addresses = addresses[:min(len(addresses), limit)]
+
+	result := bcparser.SubscriptionsPage{
+		Subscriptions: make([]bcparser.Subscription, 0, len(addresses)),
+		Total:         total,
+	}
+	for _, address := range addresses {
+		result.Subscriptions = append(result.Subscriptions, cloneSubscription(ts.records[address]))
+	}
+	if hasMore {
+		result.NextCursor = addresses[len(addresses)-1]
+	}
+
+	return result
+}
+
+// watchlist returns the addresses watched by any tenant as a map, so they can be looked up without locking.
+func (s *subscriptions) watchlist() map[string]struct{} {
+	return s.addresses.ToSimpleMap()
+}
+
+// tenantWatchlist returns the addresses subscribed by a tenant as a map.
+func (s *subscriptions) tenantWatchlist(tenant string) map[string]struct{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	ts, ok := s.tenants[tenant]
+	if !ok {
+		return make(map[string]struct{})
+	}
+
+	return ts.addresses.ToSimpleMap()
+}
+
+// all returns the subscriptions of every tenant by the tenant ID.
+func (s *subscriptions) all() map[string][]bcparser.Subscription {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make(map[string][]bcparser.Subscription, len(s.tenants))
+	for tenant, ts := range s.tenants {
+		subs := make([]bcparser.Subscription, 0, len(ts.records))
+		for _, address := range set.Sorted(ts.addresses) {
+			subs = append(subs, cloneSubscription(ts.records[address]))
+		}
+		result[tenant] = subs
+	}
+
+	return result
+}
+
+// replace swaps the subscriptions of all tenants with the ones of other. The addresses set is updated in place since
+// it is read without holding the lock.
+func (s *subscriptions) replace(other *subscriptions) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for address := range s.addresses.ToSimpleMap() {
+		if !other.addresses.Contains(address) {
+			s.addresses.Remove(address)
+		}
+	}
+	s.addresses.AddAll(set.Sorted(other.addresses)...)
+	s.tenants = other.tenants
+	s.watchers = other.watchers
+}
+
+// indexTags adds an address to the index of the given tags.
+func (ts *tenantSubscriptions) indexTags(address string, tags []string) {
+	for _, tag := range tags {
+		if _, ok := ts.byTag[tag]; !ok {
+			ts.byTag[tag] = set.New[string]()
+		}
+		ts.byTag[tag].Add(address)
+	}
+}
+
+// unindexTags removes an address from the index of the given tags.
+func (ts *tenantSubscriptions) unindexTags(address string, tags []string) {
+	for _, tag := range tags {
+		tagged, ok := ts.byTag[tag]
+		if !ok {
+			continue
+		}
+
+		tagged.Remove(address)
+		if tagged.Len() == 0 {
+			delete(ts.byTag, tag)
+		}
+	}
+}
+
+// normalizeTags trims the tags and removes empty and duplicate ones.
+func normalizeTags(tags []string) []string {
+	result := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || slices.Contains(result, tag) {
+			continue
+		}
+
+		result = append(result, tag)
+	}
+
+	return result
+}
+
+func cloneSubscription(sub *bcparser.Subscription) bcparser.Subscription {
+	result := *sub
+	result.Tags = slices.Clone(sub.Tags)
+
+	return result
+}
@@ -0,0 +1,16 @@
+package bcparser
+
+import "blockbook/pkg/errors"
+
+var (
+	ErrAddressNotSubscribed = errors.New("address not subscribed")
+	ErrBalanceNotAvailable  = errors.New("balance is not available yet")
+	ErrTransactionNotFound  = errors.New("transaction not found")
+	ErrBlockNotFound        = errors.New("block not found")
+	ErrParserNotReady       = errors.New("parser has not finished its initial scan")
+	ErrIndexerLagging       = errors.New("indexer is lagging behind the chain head")
+	ErrIndexerStalled       = errors.New("indexer has not made progress recently")
+	ErrInvalidBlockRange    = errors.New("block range is invalid")
+	ErrReindexInProgress    = errors.New("another re-index is in progress")
+	ErrInvalidIndexInterval = errors.New("index interval must be positive")
+)
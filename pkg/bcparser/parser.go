@@ -1,17 +1,209 @@
 package bcparser
 
-import "blockbook/pkg/bcclient"
+import (
+	"blockbook/pkg/bcclient"
+	"context"
+	"math/big"
+	"time"
+)
+
+// Subscription is an address in the watchlist alongside its metadata.
+type Subscription struct {
+	Address string `json:"address"`
+	// Label is a human-readable name of the address, e.g. the wallet name.
+	Label string   `json:"label,omitempty"`
+	Tags  []string `json:"tags"`
+	// OwnerID is the ID of the customer which the address belongs to.
+	OwnerID   string    `json:"ownerId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SubscriptionUpdate contains the metadata fields of a subscription which should be updated. Nil fields are left
+// untouched.
+type SubscriptionUpdate struct {
+	Label   *string
+	Tags    *[]string
+	OwnerID *string
+}
+
+// SubscriptionsQuery filters and paginates the subscriptions.
+type SubscriptionsQuery struct {
+	// Tag limits the result to subscriptions having the tag.
+	Tag string
+	// Prefix limits the result to addresses starting with it. It is case-insensitive.
+	Prefix string
+	// After is the cursor of the page, Only addresses after it are returned. Use NextCursor of the previous page or
+	// leave it empty for the first page.
+	After string
+	Limit int
+}
+
+// SubscriptionsPage is a page of subscriptions ordered by address.
+type SubscriptionsPage struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+	// Total is the number of all subscriptions matching the query.
+	Total int `json:"total"`
+	// NextCursor is empty when there are no more pages.
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Balance is the native balance of an address at a given block.
+type Balance struct {
+	Amount      *big.Int `json:"amount"`
+	BlockNumber uint64   `json:"blockNumber"`
+}
+
+// Counterparty is an address which has exchanged transactions with a subscribed address.
+type Counterparty struct {
+	Address string   `json:"address"`
+	TxCount uint64   `json:"txCount"`
+	Volume  *big.Int `json:"volume"`
+}
+
+// Stats contains the running aggregates of a subscribed address, calculated from the transactions indexed since it has
+// been subscribed. Amounts of reverted transactions are not included in the totals.
+type Stats struct {
+	TotalReceived     *big.Int       `json:"totalReceived"`
+	TotalSent         *big.Int       `json:"totalSent"`
+	TxCountIn         uint64         `json:"txCountIn"`
+	TxCountOut        uint64         `json:"txCountOut"`
+	FirstSeenBlock    uint64         `json:"firstSeenBlock,omitempty"`
+	LastSeenBlock     uint64         `json:"lastSeenBlock,omitempty"`
+	TopCounterparties []Counterparty `json:"topCounterparties"`
+}
+
+// IndexedTransaction is a transaction alongside the subscribed addresses involved in it.
+type IndexedTransaction struct {
+	bcclient.Transaction
+	WatchedAddresses []string `json:"watchedAddresses"`
+	// Subscriptions contains the current subscription records of WatchedAddresses which are still subscribed.
+	Subscriptions []Subscription `json:"subscriptions"`
+	// Indexed is false when the transaction has not been found in the index and has been looked up from the blockchain.
+	Indexed bool `json:"indexed"`
+}
+
+// BlockDetail contains the header information of a block and the transactions of subscribed addresses inside it.
+type BlockDetail struct {
+	Number              uint64                  `json:"number"`
+	Hash                string                  `json:"hash"`
+	ParentHash          string                  `json:"parentHash"`
+	Timestamp           time.Time               `json:"timestamp"`
+	TransactionCount    int                     `json:"transactionCount"`
+	WatchedTransactions []*bcclient.Transaction `json:"watchedTransactions"`
+	// Indexed is false when the block has been looked up from the blockchain, In that case WatchedTransactions is
+	// calculated using the current watchlist.
+	Indexed bool `json:"indexed"`
+}
+
+// IndexerState describes what the indexer is currently doing.
+type IndexerState string
+
+const (
+	// IndexerStateInitializing means the indexer has not finished its initial scan yet.
+	IndexerStateInitializing IndexerState = "initializing"
+	// IndexerStateSyncing means the indexer is indexing new blocks.
+	IndexerStateSyncing IndexerState = "syncing"
+	// IndexerStateIdle means the indexer has caught up with the chain head and is waiting for new blocks.
+	IndexerStateIdle IndexerState = "idle"
+	// IndexerStateFailing means the last attempt to index new blocks has failed.
+	IndexerStateFailing IndexerState = "failing"
+	// IndexerStatePaused means indexing new blocks has been paused by an operator.
+	IndexerStatePaused IndexerState = "paused"
+)
+
+// Status describes the progress of the indexer.
+type Status struct {
+	ChainHead        uint64       `json:"chainHead"`
+	LastIndexedBlock uint64       `json:"lastIndexedBlock"`
+	Lag              uint64       `json:"lag"`
+	State            IndexerState `json:"state"`
+}
 
 // Parser can be used to retrieve latest transactions of subscribed addresses on a blockchain.
+//
+// Subscriptions are owned by tenants. Each tenant has its own watchlist and only sees the data of the addresses it has
+// subscribed to, While an address is indexed as long as at least one tenant has subscribed to it.
 type Parser interface {
 	// CurrentBlockNumber returns the latest indexed block number.
 	CurrentBlockNumber() uint64
-	// Subscribe can be used to add an address to the watchlist. Returns false if address is already subscribed, Otherwise returns true.
-	Subscribe(address string) bool
-	// Unsubscribe can be used to remove an address from the watchlist. Returns false if address is not subscribed, Otherwise returns true.
-	Unsubscribe(address string) bool
-	// Transactions returns the latest transaction of an address. If address is not subscribed, nil is returned.
-	Transactions(address string) []*bcclient.Transaction
+	// Subscribe can be used to add an address to the watchlist of a tenant. CreatedAt of the subscription is set by the
+	// parser. Returns false if address is already subscribed by the tenant, Otherwise returns true.
+	Subscribe(tenant string, subscription Subscription) bool
+	// Unsubscribe can be used to remove an address from the watchlist of a tenant. Returns false if address is not
+	// subscribed by the tenant, Otherwise returns true.
+	Unsubscribe(tenant string, address string) bool
+	// SubscribeMany adds a list of subscriptions to the watchlist of a tenant at once. The returned slice indicates
+	// whether each address was subscribed or not, In the same order as the given subscriptions.
+	SubscribeMany(tenant string, subscriptions []Subscription) []bool
+	// UnsubscribeMany removes a list of addresses from the watchlist of a tenant at once. The returned slice indicates
+	// whether each address was unsubscribed or not, In the same order as the given addresses.
+	UnsubscribeMany(tenant string, addresses []string) []bool
+	// Subscription returns the subscription record of an address. ErrAddressNotSubscribed is returned if the address is
+	// not subscribed by the tenant.
+	Subscription(tenant string, address string) (Subscription, error)
+	// UpdateSubscription updates the metadata of a subscription and returns the updated record. ErrAddressNotSubscribed
+	// is returned if the address is not subscribed by the tenant.
+	UpdateSubscription(tenant string, address string, update SubscriptionUpdate) (Subscription, error)
+	// Subscriptions returns a page of the subscriptions of a tenant matching the query.
+	Subscriptions(tenant string, query SubscriptionsQuery) SubscriptionsPage
+	// Transactions returns the latest transaction of an address. If address is not subscribed by the tenant, nil is
+	// returned.
+	Transactions(tenant string, address string) []*bcclient.Transaction
+	// Balance returns the cached native balance of a subscribed address. ErrAddressNotSubscribed is returned if the
+	// address is not subscribed by the tenant and ErrBalanceNotAvailable if the balance has not been fetched yet.
+	Balance(tenant string, address string) (Balance, error)
+	// Stats returns the aggregate statistics of a subscribed address. ErrAddressNotSubscribed is returned if the address
+	// is not subscribed by the tenant.
+	Stats(tenant string, address string) (Stats, error)
+	// Transaction returns a transaction by its hash. Indexed transactions are served from memory, Otherwise the
+	// transaction is looked up from the blockchain. Only the watched addresses of the tenant are included in the
+	// result. ErrTransactionNotFound is returned if the transaction does not exist.
+	Transaction(ctx context.Context, tenant string, hash string) (IndexedTransaction, error)
+	// Block returns the details of a block. Recently indexed blocks are served from memory, Otherwise the block is looked
+	// up from the blockchain. Only the transactions of the addresses watched by the tenant are included in the result.
+	// ErrBlockNotFound is returned if the block does not exist.
+	Block(ctx context.Context, tenant string, number uint64) (BlockDetail, error)
+	// Status returns the current progress of the indexer.
+	Status() Status
 	// Ready is used to be aware of when the parser has done its initial scan, and it's ready for usage.
 	Ready() <-chan struct{}
 }
+
+// ReindexStatus is the progress of a re-index of a block range.
+type ReindexStatus struct {
+	From uint64 `json:"from"`
+	To   uint64 `json:"to"`
+	// Current is the block which is being re-indexed.
+	Current uint64 `json:"current"`
+	Running bool   `json:"running"`
+	// Error is the reason the re-index has been stopped, If it has failed.
+	Error string `json:"error,omitempty"`
+}
+
+// IndexerInfo describes the indexer for operators.
+type IndexerInfo struct {
+	Status
+	Paused        bool
+	IndexInterval time.Duration
+	// LastReindex is the progress of the latest re-index. It's nil if no re-index has been requested yet.
+	LastReindex *ReindexStatus
+}
+
+// Indexer is implemented by parsers which their indexing loop can be controlled at runtime.
+type Indexer interface {
+	// Pause stops indexing new blocks until Resume is called. Already indexed data is still served.
+	Pause()
+	// Resume continues indexing new blocks after Pause.
+	Resume()
+	// SetIndexInterval changes the interval in which the chain is checked for new blocks.
+	SetIndexInterval(interval time.Duration) error
+	// Reindex indexes the already indexed blocks in the given range again in the background, e.g. to backfill the
+	// transaction history of recently subscribed addresses Or to recover from wrong data returned by the node. The
+	// indexed transactions of each block are replaced with the fetched ones, Their stats are reverted and counted
+	// again, And the balances of the affected addresses are fetched again. Transactions older than all transactions
+	// of a full history are not added to it nor counted in its stats. ErrInvalidBlockRange is returned if the range is
+	// invalid and ErrReindexInProgress if another re-index is running.
+	Reindex(from uint64, to uint64) error
+	// IndexerInfo returns the current state of the indexer.
+	IndexerInfo() IndexerInfo
+}
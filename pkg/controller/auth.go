@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	APIKeyName = "apiKey"
+
+	// APIKeyHeader is the header which API keys are read from. Keys can also be sent as a bearer token in the
+	// Authorization header.
+	APIKeyHeader        = "X-API-Key"
+	authorizationHeader = "Authorization"
+	bearerPrefix        = "Bearer "
+
+	// WildcardScope grants access to all scopes.
+	WildcardScope = "*"
+	// AnonymousKeyID is the ID of the key used by Anonymous.
+	AnonymousKeyID = "anonymous"
+)
+
+// APIKey is a client credential alongside the scopes it has been granted.
+type APIKey struct {
+	ID string
+	// Hash is the hex encoded SHA-256 hash of the key. Plain keys are never stored.
+	Hash   string
+	Scopes []string
+	// TenantID is the tenant which the key belongs to. Keys of the same tenant share their data, If it's empty the key
+	// is a tenant on its own.
+	TenantID string
+}
+
+// HasScope checks whether the key has been granted a scope or not.
+func (k APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, WildcardScope)
+}
+
+// Tenant returns the ID of the tenant which the key belongs to.
+func (k APIKey) Tenant() string {
+	if k.TenantID != "" {
+		return k.TenantID
+	}
+
+	return k.ID
+}
+
+// APIKeyStore is used by Authenticate to look up API keys.
+type APIKeyStore interface {
+	// FindByHash returns the key with the given hash. Returns false if the key does not exist.
+	FindByHash(hash string) (APIKey, bool)
+}
+
+// MemoryAPIKeyStore is an in-memory implementation of APIKeyStore.
+type MemoryAPIKeyStore struct {
+	keys map[string]APIKey
+}
+
+// This piece of code is to ensure that a type implements a certain interface at compile time.
+// More info: https://github.com/uber-go/guide/blob/master/style.md#verify-interface-compliance
+var _ APIKeyStore = (*MemoryAPIKeyStore)(nil)
+
+func (s *MemoryAPIKeyStore) FindByHash(hash string) (APIKey, bool) {
+	key, ok := s.keys[hash]
+
+	return key, ok
+}
+
+func NewMemoryAPIKeyStore(keys ...APIKey) *MemoryAPIKeyStore {
+	s := &MemoryAPIKeyStore{
+		keys: make(map[string]APIKey, len(keys)),
+	}
+	for _, key := range keys {
+		key.Hash = strings.ToLower(key.Hash)
+		s.keys[key.Hash] = key
+	}
+
+	return s
+}
+
+// HashAPIKey returns the hash of a plain API key as it is stored in APIKeyStore.
+func HashAPIKey(key string) string {
+	hash := sha256.Sum256([]byte(key))
+
+	return hex.EncodeToString(hash[:])
+}
+
+// Authenticate rejects requests without a valid API key. The key is stored in the context, so it can be retrieved
+// using GetAPIKey, and its ID is added to the request logger.
+func Authenticate(store APIKeyStore) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		plainKey := extractAPIKey(c)
+		if plainKey == "" {
+			WriteError(ErrMissingAPIKey, c)
+			c.Abort()
+
+			return
+		}
+
+		key, ok := store.FindByHash(HashAPIKey(plainKey))
+		if !ok {
+			WriteError(ErrInvalidAPIKey, c)
+			c.Abort()
+
+			return
+		}
+
+		setAPIKey(key, c)
+		c.Next()
+	}
+}
+
+// Anonymous grants all scopes to every request. It is used instead of Authenticate when authentication is disabled.
+func Anonymous() gin.HandlerFunc {
+	key := APIKey{
+		ID:     AnonymousKeyID,
+		Scopes: []string{WildcardScope},
+	}
+
+	return func(c *gin.Context) {
+		setAPIKey(key, c)
+		c.Next()
+	}
+}
+
+// RequireScopes rejects requests which their API key does not have all given scopes. It should be used after
+// Authenticate or Anonymous.
+func RequireScopes(scopes ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := GetAPIKey(c)
+		if !ok {
+			WriteError(ErrMissingAPIKey, c)
+			c.Abort()
+
+			return
+		}
+
+		for _, scope := range scopes {
+			if !key.HasScope(scope) {
+				WriteError(ErrInsufficientScope, c)
+				c.Abort()
+
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// GetAPIKey returns the API key of the request. Returns false if the request has not been authenticated.
+func GetAPIKey(c *gin.Context) (APIKey, bool) {
+	rawKey, ok := c.Get(APIKeyName)
+	if !ok {
+		return APIKey{}, false
+	}
+	key, ok := rawKey.(APIKey)
+
+	return key, ok
+}
+
+// GetTenant returns the tenant of the request's API key. Requests without an API key belong to the anonymous tenant.
+func GetTenant(c *gin.Context) string {
+	key, ok := GetAPIKey(c)
+	if !ok {
+		return AnonymousKeyID
+	}
+
+	return key.Tenant()
+}
+
+func setAPIKey(key APIKey, c *gin.Context) {
+	c.Set(APIKeyName, key)
+	c.Set(LoggerName, GetLogger(c).With(zap.String("keyId", key.ID), zap.String("tenant", key.Tenant())))
+}
+
+func extractAPIKey(c *gin.Context) string {
+	if key := c.GetHeader(APIKeyHeader); key != "" {
+		return key
+	}
+
+	authorization := c.GetHeader(authorizationHeader)
+	if strings.HasPrefix(authorization, bearerPrefix) {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, bearerPrefix))
+	}
+
+	return ""
+}
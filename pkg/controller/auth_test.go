@@ -0,0 +1,115 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+const (
+	testPlainKey   = "plain-key"
+	testOtherKey   = "other-key"
+	testReadScope  = "read:things"
+	testWriteScope = "write:things"
+)
+
+type testResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Result  struct {
+		Type   string `json:"type"`
+		Tenant string `json:"tenant"`
+	} `json:"result"`
+}
+
+// newTestEngine creates an engine which serves GET / behind the given middlewares, And responds with the tenant of the
+// request.
+func newTestEngine(middlewares ...gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+
+	e := gin.New()
+	e.Use(AddLogger(zap.NewNop()))
+	e.GET("/", append(middlewares, func(c *gin.Context) {
+		WriteSuccess(gin.H{"tenant": GetTenant(c)}, c)
+	})...)
+
+	return e
+}
+
+func serveTestRequest(t *testing.T, handler http.Handler, header http.Header) (*httptest.ResponseRecorder, testResponse) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for name, values := range header {
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+	handler.ServeHTTP(rec, req)
+
+	var res testResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+
+	return rec, res
+}
+
+func newTestStore() *MemoryAPIKeyStore {
+	return NewMemoryAPIKeyStore(
+		// hashes are matched case-insensitively.
+		APIKey{ID: "reader", Hash: strings.ToUpper(HashAPIKey(testPlainKey)), Scopes: []string{testReadScope}, TenantID: "team"},
+		APIKey{ID: "operator", Hash: HashAPIKey(testOtherKey), Scopes: []string{WildcardScope}},
+	)
+}
+
+func TestAuthenticate(t *testing.T) {
+	handler := newTestEngine(Authenticate(newTestStore()))
+
+	rec, res := serveTestRequest(t, handler, nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, ErrMissingAPIKey.Error(), res.Message)
+
+	rec, res = serveTestRequest(t, handler, http.Header{APIKeyHeader: {"wrong-key"}})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, ErrInvalidAPIKey.Error(), res.Message)
+
+	rec, res = serveTestRequest(t, handler, http.Header{APIKeyHeader: {testPlainKey}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "team", res.Result.Tenant)
+
+	// keys without a tenant are a tenant on their own.
+	rec, res = serveTestRequest(t, handler, http.Header{authorizationHeader: {bearerPrefix + testOtherKey}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "operator", res.Result.Tenant)
+}
+
+func TestRequireScopes(t *testing.T) {
+	store := newTestStore()
+
+	rec, _ := serveTestRequest(t, newTestEngine(Authenticate(store), RequireScopes(testReadScope)), http.Header{APIKeyHeader: {testPlainKey}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec, res := serveTestRequest(t, newTestEngine(Authenticate(store), RequireScopes(testReadScope, testWriteScope)), http.Header{APIKeyHeader: {testPlainKey}})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Equal(t, ErrInsufficientScope.Error(), res.Message)
+
+	rec, _ = serveTestRequest(t, newTestEngine(Authenticate(store), RequireScopes(testReadScope, testWriteScope)), http.Header{APIKeyHeader: {testOtherKey}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// requests which are not authenticated are rejected instead of being let through.
+	rec, _ = serveTestRequest(t, newTestEngine(RequireScopes(testReadScope)), nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestAnonymous(t *testing.T) {
+	rec, res := serveTestRequest(t, newTestEngine(Anonymous(), RequireScopes(testReadScope, testWriteScope)), nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, AnonymousKeyID, res.Result.Tenant)
+}
@@ -27,6 +27,18 @@ type Controller interface {
 	RegisterHandlers(engine *gin.RouterGroup)
 }
 
+// Normalizer is implemented by models which bring their fields into a canonical form, e.g. checksummed addresses. The
+// Bind functions call Normalize after a model is bound and validated.
+type Normalizer interface {
+	Normalize()
+}
+
+func normalize[T any](result *T) {
+	if normalizer, ok := any(result).(Normalizer); ok {
+		normalizer.Normalize()
+	}
+}
+
 //nolint:wrapcheck
 func BindBody[T any](c *gin.Context) (T, error) {
 	var result T
@@ -39,6 +51,7 @@ func BindBody[T any](c *gin.Context) (T, error) {
 
 		return result, ErrMalformedRequest
 	}
+	normalize(&result)
 
 	return result, nil
 }
@@ -55,6 +68,24 @@ func BindUri[T any](c *gin.Context) (T, error) {
 
 		return result, ErrMalformedRequest
 	}
+	normalize(&result)
+
+	return result, nil
+}
+
+//nolint:wrapcheck
+func BindQuery[T any](c *gin.Context) (T, error) {
+	var result T
+	err := c.ShouldBindQuery(&result)
+	if err != nil {
+		var validationErrs validator.ValidationErrors
+		if errors.As(err, &validationErrs) {
+			return result, errors.ConvertToValidationError(validationErrs)
+		}
+
+		return result, ErrMalformedRequest
+	}
+	normalize(&result)
 
 	return result, nil
 }
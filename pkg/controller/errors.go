@@ -8,3 +8,7 @@ import (
 
 var ErrInvalidValidatorEngine = errors.New("could not get gin validator engine")
 var ErrMalformedRequest = errors.New("could not unmarshal request payload", errors.WithStatusCode(http.StatusBadRequest), errors.WithType("MalformedRequest"))
+var ErrMissingAPIKey = errors.New("api key is required", errors.WithStatusCode(http.StatusUnauthorized), errors.WithType("Unauthorized"))
+var ErrInvalidAPIKey = errors.New("api key is invalid", errors.WithStatusCode(http.StatusUnauthorized), errors.WithType("Unauthorized"))
+var ErrInsufficientScope = errors.New("api key does not have the required scopes", errors.WithStatusCode(http.StatusForbidden), errors.WithType("Forbidden"))
+var ErrRateLimited = errors.New("too many requests, please retry later", errors.WithStatusCode(http.StatusTooManyRequests), errors.WithType("TooManyRequests"))
@@ -4,9 +4,10 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-// Group is used to add a prefix to a list of Controllers.
+// Group is used to add a prefix and optionally some middlewares to a list of Controllers.
 type Group struct {
 	controllers []Controller
+	middlewares []gin.HandlerFunc
 	prefix      string
 }
 
@@ -19,6 +20,7 @@ func (g Group) PathPrefix() string {
 }
 
 func (g Group) RegisterHandlers(e *gin.RouterGroup) {
+	e.Use(g.middlewares...)
 	for _, controller := range g.controllers {
 		controller.RegisterHandlers(e.Group(controller.PathPrefix()))
 	}
@@ -30,3 +32,12 @@ func NewGroup(prefix string, controllers ...Controller) Controller {
 		prefix:      prefix,
 	}
 }
+
+// NewGroupWithMiddlewares creates a Group which the given middlewares are applied to all of its Controllers.
+func NewGroupWithMiddlewares(prefix string, middlewares []gin.HandlerFunc, controllers ...Controller) Controller {
+	return Group{
+		controllers: controllers,
+		middlewares: middlewares,
+		prefix:      prefix,
+	}
+}
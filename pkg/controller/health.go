@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// HealthCheckTimeout is the maximum duration which a single health check can take.
+	HealthCheckTimeout = 5 * time.Second
+
+	unhealthyMessage = "Service is unhealthy"
+)
+
+// HealthCheck reports the health of a component. A nil error means the component is healthy.
+type HealthCheck func(ctx context.Context) error
+
+// HealthCheckResult is the outcome of a single health check.
+type HealthCheckResult struct {
+	Healthy bool   `json:"healthy"`
+	Error   string `json:"error,omitempty"`
+}
+
+// HealthReport is the outcome of all health checks of a kind.
+type HealthReport struct {
+	Healthy bool                         `json:"healthy"`
+	Checks  map[string]HealthCheckResult `json:"checks"`
+}
+
+// HealthRegistry keeps the readiness and liveness checks which components register into. Readiness checks tell
+// whether the service can accept traffic and liveness checks tell whether it should be restarted.
+type HealthRegistry struct {
+	mu        sync.RWMutex
+	readiness map[string]HealthCheck
+	liveness  map[string]HealthCheck
+}
+
+// AddReadinessCheck registers a readiness check. An existing check with the same name is replaced.
+func (r *HealthRegistry) AddReadinessCheck(name string, check HealthCheck) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.readiness[name] = check
+}
+
+// AddLivenessCheck registers a liveness check. An existing check with the same name is replaced.
+func (r *HealthRegistry) AddLivenessCheck(name string, check HealthCheck) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.liveness[name] = check
+}
+
+// CheckReadiness runs all readiness checks concurrently.
+func (r *HealthRegistry) CheckReadiness(ctx context.Context) HealthReport {
+	r.mu.RLock()
+	checks := cloneChecks(r.readiness)
+	r.mu.RUnlock()
+
+	return runChecks(ctx, checks)
+}
+
+// CheckLiveness runs all liveness checks concurrently.
+func (r *HealthRegistry) CheckLiveness(ctx context.Context) HealthReport {
+	r.mu.RLock()
+	checks := cloneChecks(r.liveness)
+	r.mu.RUnlock()
+
+	return runChecks(ctx, checks)
+}
+
+func cloneChecks(checks map[string]HealthCheck) map[string]HealthCheck {
+	result := make(map[string]HealthCheck, len(checks))
+	for name, check := range checks {
+		result[name] = check
+	}
+
+	return result
+}
+
+func runChecks(ctx context.Context, checks map[string]HealthCheck) HealthReport {
+	report := HealthReport{
+		Healthy: true,
+		Checks:  make(map[string]HealthCheckResult, len(checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(ctx, HealthCheckTimeout)
+			defer cancel()
+
+			result := HealthCheckResult{Healthy: true}
+			if err := check(ctx); err != nil {
+				result = HealthCheckResult{Healthy: false, Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+			report.Healthy = report.Healthy && result.Healthy
+		}()
+	}
+	wg.Wait()
+
+	return report
+}
+
+// WriteHealthReport writes the report using the standard response envelope. Unhealthy reports are responded with
+// 503 status code.
+func WriteHealthReport(report HealthReport, c *gin.Context) {
+	if !report.Healthy {
+		c.JSON(http.StatusServiceUnavailable, getGinResponse(false, report, unhealthyMessage))
+
+		return
+	}
+
+	WriteSuccess(report, c)
+}
+
+func NewHealthRegistry() *HealthRegistry {
+	return &HealthRegistry{
+		readiness: make(map[string]HealthCheck),
+		liveness:  make(map[string]HealthCheck),
+	}
+}
@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"blockbook/pkg/errors"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthRegistry(t *testing.T) {
+	registry := NewHealthRegistry()
+
+	report := registry.CheckReadiness(context.Background())
+	assert.True(t, report.Healthy)
+	assert.Empty(t, report.Checks)
+
+	registry.AddReadinessCheck("db", func(ctx context.Context) error {
+		return nil
+	})
+	registry.AddReadinessCheck("rpc", func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); !ok {
+			return errors.New("check has no deadline")
+		}
+
+		return errors.New("node is down")
+	})
+	report = registry.CheckReadiness(context.Background())
+	assert.False(t, report.Healthy)
+	assert.Equal(t, map[string]HealthCheckResult{
+		"db":  {Healthy: true},
+		"rpc": {Healthy: false, Error: "node is down"},
+	}, report.Checks)
+
+	// liveness checks are kept apart from the readiness checks.
+	assert.True(t, registry.CheckLiveness(context.Background()).Healthy)
+
+	registry.AddReadinessCheck("rpc", func(ctx context.Context) error {
+		return nil
+	})
+	assert.True(t, registry.CheckReadiness(context.Background()).Healthy)
+}
+
+func TestWriteHealthReport(t *testing.T) {
+	registry := NewHealthRegistry()
+	handler := newTestEngine(func(c *gin.Context) {
+		WriteHealthReport(registry.CheckLiveness(c.Request.Context()), c)
+		c.Abort()
+	})
+
+	rec, res := serveTestRequest(t, handler, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, res.Success)
+
+	registry.AddLivenessCheck("indexer", func(ctx context.Context) error {
+		return errors.New("indexer is stuck")
+	})
+	rec, res = serveTestRequest(t, handler, nil)
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.False(t, res.Success)
+	assert.Equal(t, unhealthyMessage, res.Message)
+}
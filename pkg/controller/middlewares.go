@@ -2,23 +2,42 @@ package controller
 
 import (
 	"context"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/Depado/ginprom"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
 const (
+	PrometheusName = "prometheus"
+
+	// PanicsMetric counts the recovered panics, partitioned by route.
+	PanicsMetric = "panics_total"
+
 	metricsNamespace = "blockbook"
 )
 
+// Tracing starts a span for each request, Continuing the trace of the incoming W3C trace context headers if present.
+// Requests to the metrics path are not traced.
+func Tracing(serviceName, metricsPath string) gin.HandlerFunc {
+	return otelgin.Middleware(serviceName, otelgin.WithFilter(func(r *http.Request) bool {
+		return r.URL.Path != metricsPath
+	}))
+}
+
 func AddLogger(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		requestId := uuid.New().String()
 		logger := logger.With(zap.String("requestId", requestId), zap.String("path", c.FullPath()), zap.String("method", c.Request.Method))
+		if spanContext := trace.SpanContextFromContext(c.Request.Context()); spanContext.HasTraceID() {
+			logger = logger.With(zap.Stringer("traceId", spanContext.TraceID()))
+		}
 		c.Set(LoggerName, logger)
 		c.Next()
 	}
@@ -56,6 +75,42 @@ func AccessLog() gin.HandlerFunc {
 	}
 }
 
+// Recover recovers panics of the handlers, logs them alongside their stack trace using the request logger and responds
+// with an internal error. It should be used after AddLogger.
+func Recover() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				// http.ErrAbortHandler is used to abort the response silently, So it should reach the http server.
+				panic(p)
+			}
+
+			GetLogger(c).Error("recovered panic in api", zap.Any("panic", p), zap.Stack("stack"))
+
+			if prom, ok := GetPrometheus(c); ok {
+				if err := prom.IncrementCounterValue(PanicsMetric, []string{c.FullPath()}); err != nil {
+					GetLogger(c).Warn("could not increment panics metric", zap.Error(err))
+				}
+			}
+
+			if c.Writer.Written() {
+				c.Abort()
+
+				return
+			}
+			c.AbortWithStatusJSON(http.StatusInternalServerError, getGinResponse(false, gin.H{
+				"type": internalErrorType,
+			}, internalErrorMessage))
+		}()
+
+		c.Next()
+	}
+}
+
 func Prometheus(e *gin.Engine, metricsPath, subsystem, defaultHandlerName string, apiControllers map[string]Controller) gin.HandlerFunc {
 	p := ginprom.New(
 		ginprom.Engine(e),
@@ -74,5 +129,24 @@ func Prometheus(e *gin.Engine, metricsPath, subsystem, defaultHandlerName string
 		}),
 	)
 
-	return p.Instrument()
+	p.AddCustomCounter(RateLimitedRequestsMetric, "How many requests have been rejected by rate limiters, partitioned by route group and bucket type.", []string{"group", "by"})
+	p.AddCustomCounter(PanicsMetric, "How many panics have been recovered, partitioned by route.", []string{"path"})
+
+	instrument := p.Instrument()
+
+	return func(c *gin.Context) {
+		c.Set(PrometheusName, p)
+		instrument(c)
+	}
+}
+
+// GetPrometheus returns the ginprom instance of the server, It can be used by handlers to update custom metrics.
+func GetPrometheus(c *gin.Context) (*ginprom.Prometheus, bool) {
+	rawPrometheus, ok := c.Get(PrometheusName)
+	if !ok {
+		return nil, false
+	}
+	p, ok := rawPrometheus.(*ginprom.Prometheus)
+
+	return p, ok
 }
@@ -0,0 +1,43 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecover(t *testing.T) {
+	handler := newTestEngine(Recover(), func(c *gin.Context) {
+		panic("boom")
+	})
+
+	rec, res := serveTestRequest(t, handler, nil)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.False(t, res.Success)
+	assert.Equal(t, internalErrorMessage, res.Message)
+	assert.Equal(t, internalErrorType, res.Result.Type)
+}
+
+func TestRecoverKeepsWrittenResponses(t *testing.T) {
+	handler := newTestEngine(Recover(), func(c *gin.Context) {
+		WriteSuccess(gin.H{}, c)
+		panic("boom")
+	})
+
+	rec, res := serveTestRequest(t, handler, nil)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, res.Success)
+}
+
+func TestRecoverRepanicsAbortHandler(t *testing.T) {
+	handler := newTestEngine(Recover(), func(c *gin.Context) {
+		panic(http.ErrAbortHandler)
+	})
+
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"math"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"golang.org/x/time/rate"
+)
+
+const (
+	// RateLimitedRequestsMetric counts the requests rejected by the rate limiters, partitioned by route group and bucket type.
+	RateLimitedRequestsMetric = "rate_limited_requests_total"
+
+	retryAfterHeader = "Retry-After"
+
+	rateLimitByKey = "key"
+	rateLimitByIP  = "ip"
+
+	// DefaultBucketIdleTimeout is the duration which unused buckets are kept before being dropped.
+	DefaultBucketIdleTimeout = 10 * time.Minute
+)
+
+// RateLimit configures a token bucket. Limiting is disabled if RequestsPerSecond is not positive.
+type RateLimit struct {
+	// RequestsPerSecond is the rate which tokens are added to the bucket.
+	RequestsPerSecond float64
+	// Burst is the size of the bucket. Defaults to one if it's not positive.
+	Burst int
+}
+
+// Enabled checks whether the limit should be enforced or not.
+func (l RateLimit) Enabled() bool {
+	return l.RequestsPerSecond > 0
+}
+
+// GroupRateLimits contains the rate limits of a route group.
+type GroupRateLimits struct {
+	PerKey RateLimit
+	PerIP  RateLimit
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// RateLimiter keeps a token bucket for each key. Buckets which have not been used for a while are dropped to keep the
+// memory usage bounded.
+type RateLimiter struct {
+	limit       RateLimit
+	idleTimeout time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// NewRateLimiter creates a RateLimiter which gives each key a bucket based on the given limit.
+func NewRateLimiter(limit RateLimit) *RateLimiter {
+	if limit.Burst <= 0 {
+		limit.Burst = 1
+	}
+
+	return &RateLimiter{
+		limit:       limit,
+		idleTimeout: DefaultBucketIdleTimeout,
+		buckets:     make(map[string]*bucket),
+		lastSweep:   time.Now(),
+	}
+}
+
+// Allow takes a token from the bucket of the key. If the bucket is empty, It returns false alongside the duration
+// which the caller should wait before retrying.
+func (l *RateLimiter) Allow(key string) (bool, time.Duration) {
+	if !l.limit.Enabled() {
+		return true, 0
+	}
+
+	now := time.Now()
+
+	l.mu.Lock()
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(rate.Limit(l.limit.RequestsPerSecond), l.limit.Burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+	l.mu.Unlock()
+
+	reservation := b.limiter.ReserveN(now, 1)
+	delay := reservation.DelayFrom(now)
+	if delay > 0 {
+		// the request is rejected, so the token should not be consumed.
+		reservation.CancelAt(now)
+
+		return false, delay
+	}
+
+	return true, 0
+}
+
+// sweep drops idle buckets. l.mu must be held by the caller.
+func (l *RateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.idleTimeout {
+		return
+	}
+	l.lastSweep = now
+
+	for key, b := range l.buckets {
+		if now.Sub(b.lastSeen) >= l.idleTimeout {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// RateLimitByIP limits requests of each client IP. It should be used before Authenticate, So unauthenticated
+// requests are limited as well.
+func RateLimitByIP(group string, limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !allow(c, group, rateLimitByIP, limiter, c.ClientIP()) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RateLimitByKey limits requests of each API key. It should be used after Authenticate or Anonymous, Anonymous
+// requests are only limited by RateLimitByIP.
+func RateLimitByKey(group string, limiter *RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key, ok := GetAPIKey(c)
+		if ok && key.ID != AnonymousKeyID && !allow(c, group, rateLimitByKey, limiter, key.ID) {
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func allow(c *gin.Context, group, by string, limiter *RateLimiter, key string) bool {
+	ok, retryAfter := limiter.Allow(key)
+	if ok {
+		return true
+	}
+
+	if p, ok := GetPrometheus(c); ok {
+		if err := p.IncrementCounterValue(RateLimitedRequestsMetric, []string{group, by}); err != nil {
+			GetLogger(c).Warn("could not increment rate limit metric", zap.Error(err))
+		}
+	}
+
+	c.Header(retryAfterHeader, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	WriteError(ErrRateLimited, c)
+	c.Abort()
+
+	return false
+}
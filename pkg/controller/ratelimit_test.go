@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{RequestsPerSecond: 10, Burst: 2})
+
+	for range 2 {
+		ok, _ := limiter.Allow("a")
+		assert.True(t, ok)
+	}
+	ok, retryAfter := limiter.Allow("a")
+	assert.False(t, ok)
+	assert.InDelta(t, 100*time.Millisecond, retryAfter, float64(10*time.Millisecond))
+
+	// each key has its own bucket.
+	ok, _ = limiter.Allow("b")
+	assert.True(t, ok)
+
+	// rejected requests do not take a token, So the bucket is refilled after the retry delay.
+	time.Sleep(retryAfter)
+	ok, _ = limiter.Allow("a")
+	assert.True(t, ok)
+}
+
+func TestRateLimiterIsDisabledWithoutRate(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{Burst: 1})
+
+	for range 100 {
+		ok, retryAfter := limiter.Allow("a")
+		assert.True(t, ok)
+		assert.Zero(t, retryAfter)
+	}
+}
+
+func TestRateLimiterDropsIdleBuckets(t *testing.T) {
+	limiter := NewRateLimiter(RateLimit{RequestsPerSecond: 1, Burst: 1})
+	limiter.idleTimeout = 10 * time.Millisecond
+
+	limiter.Allow("a")
+	limiter.Allow("b")
+	assert.Len(t, limiter.buckets, 2)
+
+	time.Sleep(2 * limiter.idleTimeout)
+	ok, _ := limiter.Allow("a")
+	assert.True(t, ok)
+	assert.Len(t, limiter.buckets, 1)
+}
+
+func TestRateLimitByKey(t *testing.T) {
+	limit := RateLimit{RequestsPerSecond: 0.001, Burst: 1}
+	handler := newTestEngine(Authenticate(newTestStore()), RateLimitByKey("test", NewRateLimiter(limit)))
+
+	rec, _ := serveTestRequest(t, handler, http.Header{APIKeyHeader: {testPlainKey}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec, res := serveTestRequest(t, handler, http.Header{APIKeyHeader: {testPlainKey}})
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Equal(t, ErrRateLimited.Error(), res.Message)
+	assert.Equal(t, "1000", rec.Header().Get(retryAfterHeader))
+
+	rec, _ = serveTestRequest(t, handler, http.Header{APIKeyHeader: {testOtherKey}})
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// anonymous requests are only limited by ip.
+	anonymous := newTestEngine(Anonymous(), RateLimitByKey("test", NewRateLimiter(limit)))
+	for range 3 {
+		rec, _ = serveTestRequest(t, anonymous, nil)
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+}
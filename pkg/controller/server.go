@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"time"
 
+	"blockbook/pkg/errors"
 	"blockbook/pkg/logging"
 
 	"github.com/gin-contrib/pprof"
@@ -22,6 +23,11 @@ type Options struct {
 	MetricsPath        string
 	MetricsSubSystem   string
 	DefaultHandlerName string
+	// ServiceName is reported as the server name of the request spans.
+	ServiceName string
+	// TrustedProxies are the IPs and CIDRs of the reverse proxies which are allowed to set the client IP using the
+	// X-Forwarded-For header. If it's empty, The client IP is always the remote address of the connection.
+	TrustedProxies []string
 }
 
 // NewServer can be used to create a HTTP server based on GIN with some extra features like access log, prometheus metrics, pprof handlers, error handling, etc.
@@ -32,6 +38,11 @@ func NewServer(logger *zap.Logger, apiControllers map[string]Controller, options
 
 	e := gin.New()
 
+	// the client IP is used by the rate limiters, So it must not be read from headers of untrusted peers.
+	if err := e.SetTrustedProxies(options.TrustedProxies); err != nil {
+		return nil, errors.Wrap(err, "invalid trusted proxies")
+	}
+
 	pprof.Register(e)
 
 	err := setupValidator()
@@ -39,10 +50,12 @@ func NewServer(logger *zap.Logger, apiControllers map[string]Controller, options
 		return nil, err
 	}
 
+	e.Use(Tracing(options.ServiceName, options.MetricsPath))
 	e.Use(AddLogger(logger))
 	e.Use(AccessLog())
 	e.Use(RequestTimeout(options.RequestTimeout))
 	e.Use(Prometheus(e, options.MetricsPath, options.MetricsSubSystem, options.DefaultHandlerName, apiControllers))
+	e.Use(Recover())
 
 	for _, ctrl := range apiControllers {
 		ctrl.RegisterHandlers(e.Group(ctrl.PathPrefix()))
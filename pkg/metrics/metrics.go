@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"blockbook/pkg/errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// Namespace is the prometheus namespace which all metrics of the project are exported under.
+	Namespace = "blockbook"
+)
+
+// Register registers a collector using the registerer. If an identical collector is already registered, The existing
+// one is returned instead, So multiple instances of a component can share their metrics. If registerer is nil, The
+// collector is returned without being registered.
+func Register[T prometheus.Collector](registerer prometheus.Registerer, collector T) T {
+	if registerer == nil {
+		return collector
+	}
+
+	err := registerer.Register(collector)
+	if err != nil {
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			if existing, ok := alreadyRegistered.ExistingCollector.(T); ok {
+				return existing
+			}
+		}
+
+		panic(err)
+	}
+
+	return collector
+}
@@ -1,6 +1,8 @@
 package set
 
 import (
+	"cmp"
+	"slices"
 	"sync"
 
 	"golang.org/x/exp/maps"
@@ -27,6 +29,25 @@ func (s *Set[T]) Add(element T) bool {
 	return true
 }
 
+// AddAll adds all given keys to the set while acquiring the lock only once. The returned slice indicates whether each
+// key was new or not, In the same order as the given keys.
+func (s *Set[T]) AddAll(elements ...T) []bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]bool, len(elements))
+	for i, element := range elements {
+		if _, ok := s.elements[element]; ok {
+			continue
+		}
+
+		s.elements[element] = struct{}{}
+		result[i] = true
+	}
+
+	return result
+}
+
 // Remove deletes a given key from the set. Returns true if key is removed. If key does not exist in the set, Returns false.
 func (s *Set[T]) Remove(element T) bool {
 	s.mu.Lock()
@@ -51,6 +72,14 @@ func (s *Set[T]) Contains(element T) bool {
 	return exists
 }
 
+// Len returns the number of keys in the set.
+func (s *Set[T]) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.elements)
+}
+
 // ToSimpleMap converts the set to the standard golang map.
 func (s *Set[T]) ToSimpleMap() map[T]struct{} {
 	s.mu.RLock()
@@ -59,6 +88,52 @@ func (s *Set[T]) ToSimpleMap() map[T]struct{} {
 	return maps.Clone(s.elements)
 }
 
+// Sorted returns the keys of the set in ascending order.
+func Sorted[T cmp.Ordered](s *Set[T]) []T {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := maps.Keys(s.elements)
+	slices.Sort(keys)
+
+	return keys
+}
+
+// Page returns at most limit keys of the set, in ascending order, which are greater than after and match the given
+// filter alongside the total number of keys matching the filter. A nil filter matches all keys. Use the last key of a
+// page as after to get the next page, The zero value of T gets the first page. Only the keys of the page are kept
+// sorted while iterating the set, So a page costs a single pass over the set instead of sorting all of its keys.
+func Page[T cmp.Ordered](s *Set[T], after T, limit int, filter func(element T) bool) ([]T, int) {
+	var zero T
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]T, 0, max(min(limit, len(s.elements)), 0))
+	total := 0
+	for key := range s.elements {
+		if filter != nil && !filter(key) {
+			continue
+		}
+
+		total++
+		if limit <= 0 || (after != zero && key <= after) {
+			continue
+		}
+		if len(result) == limit {
+			if key >= result[limit-1] {
+				continue
+			}
+			result = result[:limit-1]
+		}
+
+		i, _ := slices.BinarySearch(result, key)
+		result = slices.Insert(result, i, key)
+	}
+
+	return result, total
+}
+
 func New[T comparable]() *Set[T] {
 	return &Set[T]{
 		elements: make(map[T]struct{}),
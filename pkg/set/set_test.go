@@ -0,0 +1,46 @@
+package set
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPage(t *testing.T) {
+	s := New[string]()
+	for i := 49; i >= 0; i-- {
+		s.Add(fmt.Sprintf("key%02d", i))
+	}
+
+	var pages [][]string
+	after := ""
+	for {
+		page, total := Page(s, after, 15, nil)
+		assert.Equal(t, 50, total)
+		if len(page) == 0 {
+			break
+		}
+
+		pages = append(pages, page)
+		after = page[len(page)-1]
+	}
+
+	assert.Len(t, pages, 4)
+	var keys []string
+	for _, page := range pages {
+		keys = append(keys, page...)
+	}
+	assert.Equal(t, Sorted(s), keys)
+
+	page, total := Page(s, "key10", 3, func(key string) bool {
+		return strings.HasSuffix(key, "5")
+	})
+	assert.Equal(t, []string{"key15", "key25", "key35"}, page)
+	assert.Equal(t, 5, total)
+
+	page, total = Page(s, "", 0, nil)
+	assert.Empty(t, page)
+	assert.Equal(t, 50, total)
+}
@@ -0,0 +1,64 @@
+package units
+
+import (
+	"blockbook/pkg/errors"
+	"math/big"
+	"strings"
+)
+
+const (
+	// EtherDecimals is the number of decimals of ether, One ether is 10^18 wei.
+	EtherDecimals = 18
+	// GweiDecimals is the number of decimals of gwei, One gwei is 10^9 wei.
+	GweiDecimals = 9
+
+	UnitWei   = "wei"
+	UnitGwei  = "gwei"
+	UnitEther = "ether"
+)
+
+var ErrUnknownUnit = errors.New("unknown unit")
+
+// UnitDecimals returns the number of decimals of a unit, i.e. the number of digits of an amount in wei which come after
+// the decimal point of the same amount in the unit.
+func UnitDecimals(unit string) (int, error) {
+	switch unit {
+	case UnitWei:
+		return 0, nil
+	case UnitGwei:
+		return GweiDecimals, nil
+	case UnitEther:
+		return EtherDecimals, nil
+	default:
+		return 0, ErrUnknownUnit
+	}
+}
+
+// Format formats an integer amount of the smallest unit of a currency as a decimal string of a unit which has decimals
+// digits after its decimal point, e.g. 1500000000000000000 with 18 decimals is formatted as 1.5. Trailing zeros of the
+// fraction are omitted.
+func Format(amount *big.Int, decimals int) string {
+	if amount == nil {
+		return "0"
+	}
+
+	digits := new(big.Int).Abs(amount).String()
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	if decimals <= 0 {
+		return sign + digits
+	}
+
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+
+	integer, fraction := digits[:len(digits)-decimals], strings.TrimRight(digits[len(digits)-decimals:], "0")
+	if fraction == "" {
+		return sign + integer
+	}
+
+	return sign + integer + "." + fraction
+}
@@ -0,0 +1,52 @@
+package units
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		amount   string
+		decimals int
+		expected string
+	}{
+		{"0", EtherDecimals, "0"},
+		{"1", EtherDecimals, "0.000000000000000001"},
+		{"1500000000000000000", EtherDecimals, "1.5"},
+		{"-1500000000000000000", EtherDecimals, "-1.5"},
+		{"12000000000000000000", EtherDecimals, "12"},
+		{"123456789", 9, "0.123456789"},
+		{"123456789", 0, "123456789"},
+	}
+
+	for _, test := range tests {
+		amount, ok := new(big.Int).SetString(test.amount, 10)
+		assert.True(t, ok)
+		assert.Equal(t, test.expected, Format(amount, test.decimals), "amount %s with %d decimals", test.amount, test.decimals)
+	}
+
+	assert.Equal(t, "0", Format(nil, EtherDecimals))
+}
+
+func TestUnitDecimals(t *testing.T) {
+	tests := []struct {
+		unit     string
+		expected int
+	}{
+		{UnitWei, 0},
+		{UnitGwei, GweiDecimals},
+		{UnitEther, EtherDecimals},
+	}
+
+	for _, test := range tests {
+		decimals, err := UnitDecimals(test.unit)
+		assert.NoError(t, err)
+		assert.Equal(t, test.expected, decimals, test.unit)
+	}
+
+	_, err := UnitDecimals("finney")
+	assert.ErrorIs(t, err, ErrUnknownUnit)
+}
@@ -18,7 +18,7 @@ function exit_handler() {
 trap exit_handler SIGTERM
 trap exit_handler SIGINT
 
-go test $(go list ./... | grep -v /vendor/) -v -race -coverprofile cover.out || true
+go test -tags integration $(go list ./... | grep -v /vendor/) -v -race -coverprofile cover.out || true
 go tool cover -func=cover.out | grep total || true
 
 # stop ganache